@@ -16,32 +16,89 @@ package auth
 // along with this program. If not, see <https://www.gnu.org/licenses/>.
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/json"
-	"fmt"
+	"glance-agent/certs"
+	"glance-agent/env"
+	"log"
+	"net"
 	"net/http"
+	"strings"
 )
 
-// AuthMiddleware validates Bearer token authentication for protected routes
-func Middleware(secretToken string) func(http.Handler) http.Handler {
+// Middleware only allows requests with a valid Bearer token that grants the given scope
+// Tokens restricted to certain IPs are rejected when used from any other address.
+// With TLS_CLIENT_AUTH=cert a verified client certificate is accepted in place of a token.
+func Middleware(scope string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authHeader := r.Header.Get("Authorization")
-			expectedToken := fmt.Sprintf("Bearer %s", secretToken)
-
-			// Check if the Authorization header matches the expected Bearer token
-			if authHeader != expectedToken {
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(http.StatusUnauthorized)
-				if err := json.NewEncoder(w).Encode(map[string]string{
-					"error": "Unauthorized: Invalid token",
-				}); err != nil {
-					http.Error(w, "Failed to encode error response", http.StatusInternalServerError)
-				}
+			if env.GetTLSOptions().ClientAuth == certs.ClientAuthCert && hasVerifiedClientCert(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			access := env.GetAccess()
+			token, found := findToken(r.Header.Get("Authorization"), access.Tokens)
+			if !found {
+				writeError(w, http.StatusUnauthorized, "Unauthorized: Invalid token")
+				return
+			}
+
+			if !token.HasScope(scope) {
+				log.Printf("Token %s is missing scope %s", token.Name, scope)
+				writeError(w, http.StatusForbidden, "Forbidden: Token does not grant the "+scope+" scope")
 				return
 			}
 
+			if len(token.AllowedIPs) > 0 {
+				clientIP := getClientIP(r, access.TrustedProxies, access.ProxyHeader)
+				ip := net.ParseIP(clientIP)
+				if ip == nil || !checkIPBlock(ip, token.AllowedIPs) {
+					log.Printf("Token %s used from disallowed IP: %s", token.Name, clientIP)
+					writeError(w, http.StatusForbidden, "Forbidden: Token is not allowed from this IP")
+					return
+				}
+			}
+
 			// Token is valid, proceed to the next handler
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// findToken returns the configured token matching the Authorization header
+// Every token hash is compared in constant time so the response time does not reveal which tokens exist.
+func findToken(authHeader string, tokens []env.Token) (env.Token, bool) {
+	presented, found := strings.CutPrefix(authHeader, "Bearer ")
+	if !found || presented == "" {
+		return env.Token{}, false
+	}
+	hash := sha256.Sum256([]byte(presented))
+
+	var match env.Token
+	matched := false
+	for _, token := range tokens {
+		if subtle.ConstantTimeCompare(hash[:], token.Hash) == 1 && !matched {
+			match = token
+			matched = true
+		}
+	}
+	return match, matched
+}
+
+// hasVerifiedClientCert reports whether the request was made with a client certificate signed by the client CA
+func hasVerifiedClientCert(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+}
+
+// writeError writes a JSON error response with the given status code
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{
+		"error": message,
+	}); err != nil {
+		http.Error(w, "Failed to encode error response", http.StatusInternalServerError)
+	}
+}
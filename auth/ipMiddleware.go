@@ -22,13 +22,24 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync/atomic"
 )
 
+// forwardedHeaders are the headers reverse proxies pass the client address in
+var forwardedHeaders = []string{"X-Forwarded-For", "Forwarded", "X-Real-IP"}
+
+// untrustedForwardingLogged records whether ignored forwarded headers have been logged,
+// so a reverse proxy missing from TRUSTED_PROXIES does not log on every request
+var untrustedForwardingLogged atomic.Bool
+
 // localIPMiddleware restricts access to local IP addresses only
 func LocalIPMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Use the same access configuration for the whole request, even if it is reloaded meanwhile
+		access := env.GetAccess()
+
 		// Get client IP address
-		clientIP := getClientIP(r)
+		clientIP := getClientIP(r, access.TrustedProxies, access.ProxyHeader)
 
 		// Check if IP is local or Whitelisted
 
@@ -36,10 +47,10 @@ func LocalIPMiddleware(next http.Handler) http.Handler {
 		// if bellow variable is TRUE you can exit immediately
 		isnotIP := ip == nil
 		var badIP bool
-		if !env.WhitelistOnlyBool {
-			badIP = isnotIP || (!isLocalIP(ip) && !isWhitelisted(ip))
+		if !access.WhitelistOnly {
+			badIP = isnotIP || (!isLocalIP(ip) && !isWhitelisted(ip, access.WhitelistIPs))
 		} else {
-			badIP = isnotIP || !isWhitelisted(ip)
+			badIP = isnotIP || !isWhitelisted(ip, access.WhitelistIPs)
 		}
 
 		if badIP {
@@ -59,20 +70,57 @@ func LocalIPMiddleware(next http.Handler) http.Handler {
 }
 
 // getClientIP extracts the real client IP address from the request
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header first
-	if xForwardedFor := r.Header.Get("X-Forwarded-For"); xForwardedFor != "" {
-		// Take the first IP in the chain
-		ips := strings.Split(xForwardedFor, ",")
-		return strings.TrimSpace(ips[0])
+// Forwarded headers can be set by any client, so they are only honoured when the request
+// comes directly from a trusted proxy. Only the header the proxy is configured to set is read,
+// as proxies commonly pass the other headers on from the client untouched. The proxy chain
+// is walked from right to left, skipping trusted proxies, and the first untrusted address is the client.
+func getClientIP(r *http.Request, trustedProxies []string, proxyHeader string) string {
+	remoteIP := getRemoteIP(r)
+	if !isTrustedProxy(remoteIP, trustedProxies) {
+		warnUntrustedForwarding(r, remoteIP)
+		return remoteIP
+	}
+
+	var chain []string
+	switch proxyHeader {
+	case env.ProxyHeaderForwarded:
+		chain = parseForwardedFor(r.Header.Values("Forwarded"))
+	case env.ProxyHeaderXRealIP:
+		// X-Real-IP holds a single address, so only the last one set counts
+		if xRealIP := r.Header.Values("X-Real-IP"); len(xRealIP) > 0 {
+			chain = []string{stripPort(strings.TrimSpace(xRealIP[len(xRealIP)-1]))}
+		}
+	default:
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, ip := range strings.Split(header, ",") {
+				chain = append(chain, stripPort(strings.TrimSpace(ip)))
+			}
+		}
+	}
+
+	if clientIP := clientIPFromChain(chain, trustedProxies); clientIP != "" {
+		return clientIP
 	}
+	return remoteIP
+}
 
-	// Check X-Real-IP header
-	if xRealIP := r.Header.Get("X-Real-IP"); xRealIP != "" {
-		return xRealIP
+// warnUntrustedForwarding logs once that forwarded headers from a peer that is not a trusted proxy
+// are ignored. Earlier versions honoured them from any peer, so an agent behind a reverse proxy
+// that is not listed in TRUSTED_PROXIES now sees the proxy address instead of the client's.
+func warnUntrustedForwarding(r *http.Request, remoteIP string) {
+	for _, header := range forwardedHeaders {
+		if r.Header.Get(header) == "" {
+			continue
+		}
+		if untrustedForwardingLogged.CompareAndSwap(false, true) {
+			log.Printf("Warning: ignoring the %s header from %s, which is not in TRUSTED_PROXIES; add the address of your reverse proxy to TRUSTED_PROXIES to check the client address it forwards (logged once)", header, remoteIP)
+		}
+		return
 	}
+}
 
-	// Fall back to RemoteAddr
+// getRemoteIP returns the IP address of the directly connected peer
+func getRemoteIP(r *http.Request) string {
 	ip, _, err := net.SplitHostPort(r.RemoteAddr)
 	if err != nil {
 		return r.RemoteAddr
@@ -80,6 +128,63 @@ func getClientIP(r *http.Request) string {
 	return ip
 }
 
+// isTrustedProxy checks if an IP address belongs to a trusted proxy
+func isTrustedProxy(ipStr string, trustedProxies []string) bool {
+	ip := net.ParseIP(ipStr)
+	if ip == nil || len(trustedProxies) == 0 {
+		return false
+	}
+	return checkIPBlock(ip, trustedProxies)
+}
+
+// clientIPFromChain walks a proxy chain from right to left and returns the first address
+// that is not a trusted proxy. Invalid entries are returned as-is so the request is denied.
+// If every entry is a trusted proxy the leftmost address is returned.
+func clientIPFromChain(chain []string, trustedProxies []string) string {
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i] == "" {
+			continue
+		}
+		if !isTrustedProxy(chain[i], trustedProxies) {
+			return chain[i]
+		}
+	}
+
+	for _, ip := range chain {
+		if ip != "" {
+			return ip
+		}
+	}
+	return ""
+}
+
+// parseForwardedFor extracts the "for" addresses from RFC 7239 Forwarded headers in order
+// e.g. `for=192.0.2.60;proto=http, for="[2001:db8:cafe::17]:4711"`
+func parseForwardedFor(headers []string) []string {
+	var chain []string
+	for _, header := range headers {
+		for _, element := range strings.Split(header, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if !found || !strings.EqualFold(key, "for") {
+					continue
+				}
+				// Obfuscated identifiers and "unknown" are kept so the request is denied
+				chain = append(chain, stripPort(strings.Trim(value, `"`)))
+			}
+		}
+	}
+	return chain
+}
+
+// stripPort removes an optional port and IPv6 brackets from an address, e.g. "[::1]:80" becomes "::1"
+func stripPort(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
+
 // isLocalIP checks if an IP address is a local/private address
 func isLocalIP(ip net.IP) bool {
 
@@ -121,9 +226,9 @@ func isLocalIP(ip net.IP) bool {
 }
 
 // IsWhitelisted checks if an IP address is whitelisted
-func isWhitelisted(ip net.IP) bool {
+func isWhitelisted(ip net.IP, whitelist []string) bool {
 
-	if len(env.WhitelistIParr) == 0 || env.WhitelistIParr == nil {
+	if len(whitelist) == 0 {
 		return false
 	}
 
@@ -131,7 +236,7 @@ func isWhitelisted(ip net.IP) bool {
 		return false
 	}
 
-	if checkIPBlock(ip, env.WhitelistIParr) {
+	if checkIPBlock(ip, whitelist) {
 		return true
 	}
 
@@ -0,0 +1,144 @@
+package auth
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"bytes"
+	"glance-agent/env"
+	"log"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGetClientIP(t *testing.T) {
+	trustedProxies := []string{"10.0.0.0/8"}
+
+	tests := []struct {
+		name        string
+		remoteAddr  string
+		proxyHeader string
+		headers     map[string][]string
+		want        string
+	}{
+		{
+			name:        "untrusted peer ignores headers",
+			remoteAddr:  "203.0.113.7:4000",
+			proxyHeader: env.ProxyHeaderXForwardedFor,
+			headers:     map[string][]string{"X-Forwarded-For": {"127.0.0.1"}},
+			want:        "203.0.113.7",
+		},
+		{
+			name:        "x-forwarded-for walked right to left",
+			remoteAddr:  "10.0.0.2:4000",
+			proxyHeader: env.ProxyHeaderXForwardedFor,
+			headers:     map[string][]string{"X-Forwarded-For": {"127.0.0.1, 198.51.100.9, 10.0.0.3"}},
+			want:        "198.51.100.9",
+		},
+		{
+			name:        "spoofed forwarded ignored when proxy sets x-forwarded-for",
+			remoteAddr:  "10.0.0.2:4000",
+			proxyHeader: env.ProxyHeaderXForwardedFor,
+			headers: map[string][]string{
+				"Forwarded":       {"for=127.0.0.1"},
+				"X-Forwarded-For": {"198.51.100.9"},
+			},
+			want: "198.51.100.9",
+		},
+		{
+			name:        "spoofed forwarded without x-forwarded-for falls back to the proxy",
+			remoteAddr:  "10.0.0.2:4000",
+			proxyHeader: env.ProxyHeaderXForwardedFor,
+			headers:     map[string][]string{"Forwarded": {"for=127.0.0.1"}},
+			want:        "10.0.0.2",
+		},
+		{
+			name:        "spoofed x-real-ip ignored when proxy sets x-forwarded-for",
+			remoteAddr:  "10.0.0.2:4000",
+			proxyHeader: env.ProxyHeaderXForwardedFor,
+			headers:     map[string][]string{"X-Real-IP": {"127.0.0.1"}},
+			want:        "10.0.0.2",
+		},
+		{
+			name:        "forwarded read when proxy sets forwarded",
+			remoteAddr:  "10.0.0.2:4000",
+			proxyHeader: env.ProxyHeaderForwarded,
+			headers: map[string][]string{
+				"Forwarded":       {`for=127.0.0.1, for="[2001:db8::17]:4711";proto=https`},
+				"X-Forwarded-For": {"127.0.0.1"},
+			},
+			want: "2001:db8::17",
+		},
+		{
+			name:        "x-real-ip read when proxy sets x-real-ip",
+			remoteAddr:  "10.0.0.2:4000",
+			proxyHeader: env.ProxyHeaderXRealIP,
+			headers: map[string][]string{
+				"X-Real-IP":       {"198.51.100.9"},
+				"X-Forwarded-For": {"127.0.0.1"},
+			},
+			want: "198.51.100.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for key, values := range tt.headers {
+				for _, value := range values {
+					r.Header.Add(key, value)
+				}
+			}
+			if got := getClientIP(r, trustedProxies, tt.proxyHeader); got != tt.want {
+				t.Errorf("getClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUntrustedForwardingWarning(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		untrustedForwardingLogged.Store(false)
+	})
+	untrustedForwardingLogged.Store(false)
+
+	request := func(remoteAddr, header string) {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remoteAddr
+		if header != "" {
+			r.Header.Set(header, "198.51.100.9")
+		}
+		getClientIP(r, []string{"10.0.0.0/8"}, env.ProxyHeaderXForwardedFor)
+	}
+
+	request("127.0.0.1:4000", "")
+	request("10.0.0.2:4000", "X-Forwarded-For")
+	if output.Len() > 0 {
+		t.Fatalf("logged without forwarded headers from an untrusted peer: %s", output.String())
+	}
+
+	request("127.0.0.1:4000", "X-Real-IP")
+	request("192.168.1.20:4000", "Forwarded")
+	logged := output.String()
+	if strings.Count(logged, "\n") != 1 || !strings.Contains(logged, "X-Real-IP header from 127.0.0.1") {
+		t.Errorf("logged %q, want a single warning naming the header and peer", logged)
+	}
+}
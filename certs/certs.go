@@ -0,0 +1,235 @@
+package certs
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Client certificate modes
+const (
+	ClientAuthNone    = "none"    // Client certificates are not requested
+	ClientAuthRequire = "require" // A verified client certificate is required in addition to a bearer token
+	ClientAuthCert    = "cert"    // A verified client certificate can be used instead of a bearer token
+)
+
+// Options configures TLS on the HTTP listener
+type Options struct {
+	CertFile     string // Path to the PEM encoded certificate chain
+	KeyFile      string // Path to the PEM encoded private key
+	SelfSigned   bool   // Generate a self-signed certificate if the files do not exist
+	ClientCAFile string // Path to the PEM encoded CA bundle used to verify client certificates
+	ClientAuth   string // Client certificate mode, one of ClientAuthNone, ClientAuthRequire or ClientAuthCert
+}
+
+// Enabled reports whether TLS is configured
+func (o Options) Enabled() bool {
+	return o.CertFile != "" || o.KeyFile != "" || o.SelfSigned
+}
+
+// Validate checks that the options are consistent
+func (o Options) Validate() error {
+	if !o.Enabled() {
+		if o.ClientCAFile != "" || o.ClientAuth != ClientAuthNone {
+			return fmt.Errorf("client certificate verification requires TLS_CERT and TLS_KEY or TLS_SELF_SIGNED")
+		}
+		return nil
+	}
+	if o.CertFile == "" || o.KeyFile == "" {
+		return fmt.Errorf("both TLS_CERT and TLS_KEY must be set")
+	}
+	switch o.ClientAuth {
+	case ClientAuthNone:
+		if o.ClientCAFile != "" {
+			return fmt.Errorf("TLS_CLIENT_AUTH must be %q or %q when TLS_CLIENT_CA is set", ClientAuthRequire, ClientAuthCert)
+		}
+	case ClientAuthRequire, ClientAuthCert:
+		if o.ClientCAFile == "" {
+			return fmt.Errorf("TLS_CLIENT_AUTH=%s requires TLS_CLIENT_CA", o.ClientAuth)
+		}
+	default:
+		return fmt.Errorf("invalid TLS_CLIENT_AUTH value %q (valid values: %s, %s, %s)", o.ClientAuth, ClientAuthNone, ClientAuthRequire, ClientAuthCert)
+	}
+	return nil
+}
+
+// NewServerConfig returns a TLS configuration for the HTTP server
+// The certificate and client CA bundle are reloaded whenever their files change.
+func NewServerConfig(o Options) (*tls.Config, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	if o.SelfSigned {
+		if err := ensureSelfSigned(o.CertFile, o.KeyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	certificate := &certReloader{certFile: o.CertFile, keyFile: o.KeyFile}
+	if err := certificate.reload(); err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certificate.getCertificate,
+	}
+
+	if o.ClientCAFile == "" {
+		return config, nil
+	}
+
+	clientCAs := &caReloader{caFile: o.ClientCAFile}
+	if err := clientCAs.reload(); err != nil {
+		return nil, err
+	}
+
+	config.ClientAuth = tls.VerifyClientCertIfGiven
+	if o.ClientAuth == ClientAuthRequire {
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	// Hand out a copy of the configuration with the current CA bundle for every connection
+	base := config.Clone()
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		connConfig := base.Clone()
+		connConfig.ClientCAs = clientCAs.getPool()
+		return connConfig, nil
+	}
+	return config, nil
+}
+
+// watchedFiles tracks the modification times of a set of files
+type watchedFiles struct {
+	paths    []string
+	modTimes []time.Time
+}
+
+// changed reports whether any of the files was modified since the last call to update
+func (f *watchedFiles) changed() bool {
+	for i, path := range f.paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			continue // Keep using the loaded files while they are being replaced
+		}
+		if i >= len(f.modTimes) || !stat.ModTime().Equal(f.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// update records the current modification times of the files
+func (f *watchedFiles) update() {
+	f.modTimes = make([]time.Time, len(f.paths))
+	for i, path := range f.paths {
+		if stat, err := os.Stat(path); err == nil {
+			f.modTimes[i] = stat.ModTime()
+		}
+	}
+}
+
+// certReloader serves a certificate and reloads it when the files change
+type certReloader struct {
+	mu          sync.Mutex
+	certFile    string
+	keyFile     string
+	certificate *tls.Certificate
+	files       watchedFiles
+}
+
+// reload loads the certificate and key from disk
+func (c *certReloader) reload() error {
+	files := watchedFiles{paths: []string{c.certFile, c.keyFile}}
+	files.update()
+
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	c.certificate = &certificate
+	c.files = files
+	return nil
+}
+
+// getCertificate returns the current certificate, reloading it first if the files changed
+// A certificate that fails to load is logged and the previous certificate is kept.
+func (c *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.files.changed() {
+		if err := c.reload(); err != nil {
+			log.Printf("Keeping previous TLS certificate: %v", err)
+			c.files.update() // Do not retry until the files change again
+		} else {
+			log.Printf("Reloaded TLS certificate from %s", c.certFile)
+		}
+	}
+	return c.certificate, nil
+}
+
+// caReloader serves a client CA pool and reloads it when the file changes
+type caReloader struct {
+	mu     sync.Mutex
+	caFile string
+	pool   *x509.CertPool
+	files  watchedFiles
+}
+
+// reload loads the CA bundle from disk
+func (c *caReloader) reload() error {
+	files := watchedFiles{paths: []string{c.caFile}}
+	files.update()
+
+	data, err := os.ReadFile(c.caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read client CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("no certificates found in client CA bundle %s", c.caFile)
+	}
+
+	c.pool = pool
+	c.files = files
+	return nil
+}
+
+// getPool returns the current CA pool, reloading it first if the file changed
+// A bundle that fails to load is logged and the previous pool is kept.
+func (c *caReloader) getPool() *x509.CertPool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.files.changed() {
+		if err := c.reload(); err != nil {
+			log.Printf("Keeping previous client CA bundle: %v", err)
+			c.files.update() // Do not retry until the file changes again
+		} else {
+			log.Printf("Reloaded client CA bundle from %s", c.caFile)
+		}
+	}
+	return c.pool
+}
@@ -0,0 +1,139 @@
+//go:build linux || windows
+
+package main
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"fmt"
+	"glance-agent/env"
+	"glance-agent/system"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+)
+
+// runCheck prints the effective configuration and host diagnostics for the check subcommand
+// Returns the exit code, which is non-zero when the configuration has a fatal error.
+func runCheck(out io.Writer) int {
+	fmt.Fprintf(out, "Glance Agent %s configuration check\n", Version)
+
+	configErr := env.GetConfigError()
+	printConfigCheck(out, configErr)
+	printThermalCheck(out)
+	printMountCheck(out)
+	printZFSCheck(out)
+
+	if configErr != nil {
+		fmt.Fprintln(out, "\nResult: configuration errors found, the agent will not start")
+		return 1
+	}
+	fmt.Fprintln(out, "\nResult: configuration is valid")
+	return 0
+}
+
+// printConfigCheck prints every configuration value with the source it was resolved from
+func printConfigCheck(out io.Writer, configErr error) {
+	fmt.Fprintln(out, "\nCONFIGURATION:")
+	envFile := env.GetEnvFile()
+	if _, err := os.Stat(envFile); envFile == "" || err != nil {
+		envFile += " (not found)"
+	}
+	fmt.Fprintf(out, "  .env file: %s\n\n", envFile)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tVALUE\tSOURCE")
+	for _, value := range env.GetValueSources() {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", value.Name, value.Value, value.Source)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\n  API tokens: %d\n", len(env.GetTokens()))
+	if configErr != nil {
+		fmt.Fprintln(out, "  ERRORS:")
+		for _, line := range strings.Split(configErr.Error(), "\n") {
+			fmt.Fprintf(out, "    %s\n", strings.TrimSpace(line))
+		}
+	}
+}
+
+// printThermalCheck prints the thermal zones and the one used for the CPU temperature
+func printThermalCheck(out io.Writer) {
+	fmt.Fprintln(out, "\nTHERMAL ZONES:")
+	thermal, err := system.DiagnoseThermalZones()
+	if err != nil {
+		fmt.Fprintf(out, "  %v\n", err)
+		return
+	}
+	if len(thermal.Zones) == 0 {
+		fmt.Fprintln(out, "  No readable thermal zones found in /sys/class/thermal")
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, zone := range thermal.Zones {
+		marker := ""
+		if zone.Name == thermal.Selected {
+			marker = "<- selected"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%.1f°C\t%s\n", zone.Name, zone.Type, zone.Temperature, marker)
+	}
+	w.Flush()
+
+	if thermal.Selected == "" {
+		fmt.Fprintf(out, "  Selected: none, %s\n", thermal.Reason)
+		return
+	}
+	fmt.Fprintf(out, "  Selected: %s, %s\n", thermal.Selected, thermal.Reason)
+}
+
+// printMountCheck prints every mount entry and whether it is reported or which rule ignores it
+func printMountCheck(out io.Writer) {
+	fmt.Fprintln(out, "\nMOUNTS:")
+	mounts, err := system.DiagnoseMounts()
+	if err != nil {
+		fmt.Fprintf(out, "  %v\n", err)
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  MOUNTPOINT\tTYPE\tDEVICE\tSTATUS")
+	for _, mount := range mounts {
+		status := "ignored: " + mount.Reason
+		if mount.Included {
+			status = "included (" + mount.Reason + ")"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", mount.Mountpoint, mount.FSType, mount.Device, status)
+	}
+	w.Flush()
+}
+
+// printZFSCheck prints whether the zfs command used for ZFS usage is available
+func printZFSCheck(out io.Writer) {
+	fmt.Fprintln(out, "\nZFS:")
+	if runtime.GOOS != "linux" {
+		fmt.Fprintln(out, "  ZFS usage is only collected on Linux")
+		return
+	}
+	path, err := exec.LookPath("zfs")
+	if err != nil {
+		fmt.Fprintln(out, "  zfs command not found on PATH, ZFS mounts cannot be reported")
+		return
+	}
+	fmt.Fprintf(out, "  zfs command found at %s\n", path)
+}
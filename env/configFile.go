@@ -0,0 +1,398 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"errors"
+	"fmt"
+	"glance-agent/certs"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// systemConfigFile is the config file used with -use-system-config when CONFIG_FILE is not set
+const systemConfigFile = "/etc/glance-agent/config.yaml"
+
+// configValue is a scalar value from the config file along with the line it was set on
+type configValue[T any] struct {
+	Value T
+	Line  int
+	Set   bool
+}
+
+// UnmarshalYAML records the line of the value before decoding it
+// A value of the wrong type is left unset, so only the type error is reported for it.
+func (v *configValue[T]) UnmarshalYAML(node *yaml.Node) error {
+	v.Line = node.Line
+	if err := node.Decode(&v.Value); err != nil {
+		return err
+	}
+	v.Set = true
+	return nil
+}
+
+// configList is a list of strings from the config file along with the line it was set on
+type configList struct {
+	Items []configValue[string]
+	Line  int
+	Set   bool
+}
+
+// UnmarshalYAML records the line of the list before decoding its items
+func (l *configList) UnmarshalYAML(node *yaml.Node) error {
+	l.Line = node.Line
+	l.Set = true
+	return node.Decode(&l.Items)
+}
+
+// values returns the items of the list
+func (l configList) values() []string {
+	values := make([]string, len(l.Items))
+	for i, item := range l.Items {
+		values[i] = strings.TrimSpace(item.Value)
+	}
+	return values
+}
+
+// configToken is an API token defined in the config file
+type configToken struct {
+	Name       configValue[string] `yaml:"name"`
+	SHA256     configValue[string] `yaml:"sha256"`
+	Scopes     configList          `yaml:"scopes"`
+	AllowedIPs configList          `yaml:"allowed_ips"`
+}
+
+// configFile is the structure of the YAML config file
+type configFile struct {
+	Listener struct {
+		Port configValue[int] `yaml:"port"`
+		TLS  struct {
+			Cert       configValue[string] `yaml:"cert"`
+			Key        configValue[string] `yaml:"key"`
+			SelfSigned configValue[bool]   `yaml:"self_signed"`
+			ClientCA   configValue[string] `yaml:"client_ca"`
+			ClientAuth configValue[string] `yaml:"client_auth"`
+		} `yaml:"tls"`
+	} `yaml:"listener"`
+	Auth struct {
+		TokensFile     configValue[string] `yaml:"tokens_file"`
+		Tokens         []configToken       `yaml:"tokens"`
+		WhitelistIPs   configList          `yaml:"whitelist_ips"`
+		WhitelistOnly  configValue[bool]   `yaml:"whitelist_only"`
+		TrustedProxies configList          `yaml:"trusted_proxies"`
+		ProxyHeader    configValue[string] `yaml:"trusted_proxy_header"`
+	} `yaml:"auth"`
+	Collectors struct {
+		Interval    configValue[string] `yaml:"interval"`
+		Disabled    configList          `yaml:"disabled"`
+		CgroupAware configValue[bool]   `yaml:"cgroup_aware"`
+	} `yaml:"collectors"`
+	Mounts struct {
+		Ignore          configList          `yaml:"ignore"`
+		OverrideIgnored configList          `yaml:"override_ignored"`
+		ZFSInterval     configValue[string] `yaml:"zfs_interval"`
+	} `yaml:"mounts"`
+	Network struct {
+		IgnoreInterfaces  configList `yaml:"ignore_interfaces"`
+		IncludeInterfaces configList `yaml:"include_interfaces"`
+	} `yaml:"network"`
+	Thermal struct {
+		Zone configValue[int] `yaml:"zone"`
+	} `yaml:"thermal"`
+	Processes struct {
+		Top           configValue[int]  `yaml:"top"`
+		RedactCmdline configValue[bool] `yaml:"redact_cmdline"`
+	} `yaml:"processes"`
+	Containers struct {
+		Socket        configValue[string] `yaml:"socket"`
+		IncludeLabels configList          `yaml:"include_labels"`
+		ExcludeLabels configList          `yaml:"exclude_labels"`
+	} `yaml:"containers"`
+	Host struct {
+		Root configValue[string] `yaml:"root"`
+		Proc configValue[string] `yaml:"proc"`
+		Sys  configValue[string] `yaml:"sys"`
+		Etc  configValue[string] `yaml:"etc"`
+	} `yaml:"host"`
+	History struct {
+		MaxMemoryMB configValue[int] `yaml:"max_memory_mb"`
+	} `yaml:"history"`
+	Exporters struct {
+		Prometheus struct {
+			Enabled configValue[bool] `yaml:"enabled"`
+		} `yaml:"prometheus"`
+		GlanceExtension struct {
+			TemplatesDir configValue[string] `yaml:"templates_dir"`
+		} `yaml:"glance_extension"`
+		Stream struct {
+			MinInterval configValue[string] `yaml:"min_interval"`
+			MaxInterval configValue[string] `yaml:"max_interval"`
+			MaxClients  configValue[int]    `yaml:"max_clients"`
+		} `yaml:"stream"`
+	} `yaml:"exporters"`
+}
+
+// collectorToggles maps the collector names used in the config file to their feature toggles
+var collectorToggles = map[string]string{
+	"cpu_load":    "DISABLE_CPU_LOAD",
+	"cpu_usage":   "DISABLE_CPU_USAGE",
+	"temperature": "DISABLE_TEMPERATURE",
+	"sensors":     "DISABLE_SENSORS",
+	"memory":      "DISABLE_MEMORY",
+	"swap":        "DISABLE_SWAP",
+	"disk":        "DISABLE_DISK",
+	"disk_io":     "DISABLE_DISK_IO",
+	"host":        "DISABLE_HOST",
+	"network":     "DISABLE_NETWORK",
+	"pressure":    "DISABLE_PRESSURE",
+	"processes":   "DISABLE_PROCESSES",
+	"containers":  "DISABLE_CONTAINERS",
+}
+
+// resolveConfigPath returns the path of the config file and where it was set, if any
+// The path is taken from -config, CONFIG_FILE in the environment or .env file, or the system config file.
+func resolveConfigPath(dotEnv map[string]string) (string, string) {
+	if flagWasSet("config") {
+		return flagValues.configPath, SourceFlag
+	}
+	if value, source, found := lookupValue("CONFIG_FILE", dotEnv, nil); found {
+		return value, source
+	}
+	if _, err := os.Stat(systemConfigFile); err == nil && useSystemConfig {
+		return systemConfigFile, SourceDefault
+	}
+	return "", SourceDefault
+}
+
+// parseConfigFile reads and validates a config file
+// Every problem is reported with its line number, not only the first one.
+func parseConfigFile(path string) (map[string]string, []Token, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer file.Close()
+
+	var config configFile
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true) // Report misspelled keys instead of ignoring them
+
+	loader := configLoader{values: make(map[string]string)}
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		loader.problems = append(loader.problems, typeErr.Errors...)
+	}
+
+	loader.load(&config)
+	if len(loader.problems) > 0 {
+		slices.SortStableFunc(loader.problems, func(a, b string) int {
+			return problemLine(a) - problemLine(b)
+		})
+		return nil, nil, fmt.Errorf("invalid config file %s:\n  %s", path, strings.Join(loader.problems, "\n  "))
+	}
+	return loader.values, loader.tokens, nil
+}
+
+// problemLine returns the line number of a problem reported as "line N: ..."
+func problemLine(problem string) int {
+	var line int
+	if _, err := fmt.Sscanf(problem, "line %d:", &line); err != nil {
+		return 0
+	}
+	return line
+}
+
+// configLoader converts a config file into configuration values and collects every problem found
+type configLoader struct {
+	values   map[string]string
+	tokens   []Token
+	problems []string
+}
+
+// problem records a problem found on a line of the config file
+func (l *configLoader) problem(line int, key, format string, args ...any) {
+	l.problems = append(l.problems, fmt.Sprintf("line %d: %s: %s", line, key, fmt.Sprintf(format, args...)))
+}
+
+// load validates the config file and stores its values
+func (l *configLoader) load(c *configFile) {
+	if c.Listener.Port.Set {
+		if c.Listener.Port.Value < 1 || c.Listener.Port.Value > 65535 {
+			l.problem(c.Listener.Port.Line, "listener.port", "must be between 1 and 65535")
+		}
+		l.values["PORT"] = strconv.Itoa(c.Listener.Port.Value)
+	}
+
+	l.setString("TLS_CERT", c.Listener.TLS.Cert)
+	l.setString("TLS_KEY", c.Listener.TLS.Key)
+	l.setBool("TLS_SELF_SIGNED", c.Listener.TLS.SelfSigned)
+	l.setString("TLS_CLIENT_CA", c.Listener.TLS.ClientCA)
+	if clientAuth := c.Listener.TLS.ClientAuth; clientAuth.Set {
+		validModes := []string{certs.ClientAuthNone, certs.ClientAuthRequire, certs.ClientAuthCert}
+		if !slices.Contains(validModes, clientAuth.Value) {
+			l.problem(clientAuth.Line, "listener.tls.client_auth", "must be one of %s", strings.Join(validModes, ", "))
+		}
+		l.setString("TLS_CLIENT_AUTH", clientAuth)
+	}
+
+	l.setString("TOKENS_FILE", c.Auth.TokensFile)
+	l.loadTokens(c.Auth.Tokens)
+	l.setIPList("WHITELIST_IPS", "auth.whitelist_ips", c.Auth.WhitelistIPs)
+	l.setBool("WHITELIST_ONLY", c.Auth.WhitelistOnly)
+	l.setIPList("TRUSTED_PROXIES", "auth.trusted_proxies", c.Auth.TrustedProxies)
+	if header := c.Auth.ProxyHeader; header.Set {
+		if !slices.ContainsFunc(proxyHeaders, func(h string) bool { return strings.EqualFold(h, header.Value) }) {
+			l.problem(header.Line, "auth.trusted_proxy_header", "must be one of %s", strings.Join(proxyHeaders, ", "))
+		}
+		l.setString("TRUSTED_PROXY_HEADER", header)
+	}
+
+	l.setInterval("COLLECT_INTERVAL", "collectors.interval", c.Collectors.Interval)
+	l.setBool("CGROUP_AWARE", c.Collectors.CgroupAware)
+	if disabled := c.Collectors.Disabled; disabled.Set {
+		for _, toggle := range collectorToggles {
+			l.values[toggle] = "false"
+		}
+		for _, item := range disabled.Items {
+			toggle, exists := collectorToggles[item.Value]
+			if !exists {
+				l.problem(item.Line, "collectors.disabled", "unknown collector %q", item.Value)
+				continue
+			}
+			l.values[toggle] = "true"
+		}
+	}
+
+	l.setList("IGNORE_MOUNTPOINTS", c.Mounts.Ignore)
+	l.setList("OVERRIDE_IGNORED_MOUNTPOINTS", c.Mounts.OverrideIgnored)
+	l.setInterval("ZFS_INTERVAL", "mounts.zfs_interval", c.Mounts.ZFSInterval)
+
+	l.setList("IGNORE_INTERFACES", c.Network.IgnoreInterfaces)
+	l.setList("INCLUDE_INTERFACES", c.Network.IncludeInterfaces)
+
+	if zone := c.Thermal.Zone; zone.Set {
+		if zone.Value < -1 {
+			l.problem(zone.Line, "thermal.zone", "must be -1 (autodetect) or a zone number")
+		}
+		l.values["THERMAL_ZONE"] = strconv.Itoa(zone.Value)
+	}
+
+	if top := c.Processes.Top; top.Set {
+		if top.Value < 1 {
+			l.problem(top.Line, "processes.top", "must be at least 1")
+		}
+		l.values["TOP_PROCESSES"] = strconv.Itoa(top.Value)
+	}
+	l.setBool("REDACT_COMMAND_LINES", c.Processes.RedactCmdline)
+
+	l.setString("DOCKER_SOCKET", c.Containers.Socket)
+	l.setList("CONTAINER_INCLUDE_LABELS", c.Containers.IncludeLabels)
+	l.setList("CONTAINER_EXCLUDE_LABELS", c.Containers.ExcludeLabels)
+
+	l.setString("HOST_ROOT", c.Host.Root)
+	l.setString("HOST_PROC", c.Host.Proc)
+	l.setString("HOST_SYS", c.Host.Sys)
+	l.setString("HOST_ETC", c.Host.Etc)
+
+	if maxMemory := c.History.MaxMemoryMB; maxMemory.Set {
+		if maxMemory.Value < 0 {
+			l.problem(maxMemory.Line, "history.max_memory_mb", "must not be negative")
+		}
+		l.values["HISTORY_MAX_MEMORY_MB"] = strconv.Itoa(maxMemory.Value)
+	}
+
+	if enabled := c.Exporters.Prometheus.Enabled; enabled.Set {
+		l.values["DISABLE_METRICS"] = strconv.FormatBool(!enabled.Value)
+	}
+	l.setString("EXTENSION_TEMPLATES_DIR", c.Exporters.GlanceExtension.TemplatesDir)
+	l.setInterval("STREAM_MIN_INTERVAL", "exporters.stream.min_interval", c.Exporters.Stream.MinInterval)
+	l.setInterval("STREAM_MAX_INTERVAL", "exporters.stream.max_interval", c.Exporters.Stream.MaxInterval)
+	if maxClients := c.Exporters.Stream.MaxClients; maxClients.Set {
+		if maxClients.Value < 0 {
+			l.problem(maxClients.Line, "exporters.stream.max_clients", "must not be negative")
+		}
+		l.values["STREAM_MAX_CLIENTS"] = strconv.Itoa(maxClients.Value)
+	}
+}
+
+// loadTokens validates the API tokens defined in the config file
+func (l *configLoader) loadTokens(tokens []configToken) {
+	names := make(map[string]bool)
+	for _, t := range tokens {
+		token, err := newToken(t.Name.Value, t.SHA256.Value, t.Scopes.values(), t.AllowedIPs.values())
+		if err != nil {
+			l.problem(t.Name.Line, "auth.tokens", "%v", err)
+			continue
+		}
+		if names[token.Name] {
+			l.problem(t.Name.Line, "auth.tokens", "duplicate token name %q", token.Name)
+			continue
+		}
+		names[token.Name] = true
+		l.tokens = append(l.tokens, token)
+	}
+}
+
+// setString stores a string value if it is set
+func (l *configLoader) setString(name string, v configValue[string]) {
+	if v.Set {
+		l.values[name] = v.Value
+	}
+}
+
+// setBool stores a boolean value if it is set
+func (l *configLoader) setBool(name string, v configValue[bool]) {
+	if v.Set {
+		l.values[name] = strconv.FormatBool(v.Value)
+	}
+}
+
+// setInterval validates and stores an interval if it is set
+func (l *configLoader) setInterval(name, key string, v configValue[string]) {
+	if !v.Set {
+		return
+	}
+	if _, err := parseInterval(v.Value); err != nil {
+		l.problem(v.Line, key, "invalid interval %q, use a duration such as 30s or 5m", v.Value)
+		return
+	}
+	l.values[name] = v.Value
+}
+
+// setList stores a list as a comma-separated value if it is set
+func (l *configLoader) setList(name string, list configList) {
+	if list.Set {
+		l.values[name] = strings.Join(list.values(), ",")
+	}
+}
+
+// setIPList validates and stores a list of IPs and CIDR ranges if it is set
+func (l *configLoader) setIPList(name, key string, list configList) {
+	for _, item := range list.Items {
+		if !isIPOrCIDR(strings.TrimSpace(item.Value)) {
+			l.problem(item.Line, key, "invalid IP or CIDR %q", item.Value)
+		}
+	}
+	l.setList(name, list)
+}
@@ -0,0 +1,174 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfigFile writes a config file for a test and returns its path
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseConfigFile(t *testing.T) {
+	content := `listener:
+  port: 9100
+auth:
+  whitelist_ips: [192.168.1.0/24, " 10.0.0.5 "]
+  trusted_proxy_header: forwarded
+  tokens:
+    - name: dashboard
+      sha256: ` + testTokenHash + `
+      scopes: [sysinfo:read]
+      allowed_ips: [10.0.0.0/8]
+collectors:
+  interval: 30s
+  disabled: [swap, containers]
+mounts:
+  ignore: [/mnt/backup, /media]
+history:
+  max_memory_mb: 0
+exporters:
+  prometheus:
+    enabled: false
+`
+	values, tokens, err := parseConfigFile(writeConfigFile(t, content))
+	if err != nil {
+		t.Fatalf("parseConfigFile() error = %v", err)
+	}
+
+	want := map[string]string{
+		"PORT":                  "9100",
+		"WHITELIST_IPS":         "192.168.1.0/24,10.0.0.5",
+		"TRUSTED_PROXY_HEADER":  "forwarded",
+		"COLLECT_INTERVAL":      "30s",
+		"IGNORE_MOUNTPOINTS":    "/mnt/backup,/media",
+		"HISTORY_MAX_MEMORY_MB": "0",
+		"DISABLE_METRICS":       "true",
+	}
+	for toggle := range maps.Values(collectorToggles) {
+		want[toggle] = "false"
+	}
+	want["DISABLE_SWAP"], want["DISABLE_CONTAINERS"] = "true", "true"
+	if !maps.Equal(values, want) {
+		t.Errorf("parseConfigFile() values = %v, want %v", values, want)
+	}
+
+	wantToken := Token{Name: "dashboard", Hash: HashToken("dashboard-token"), Scopes: []string{ScopeSysinfoRead}, AllowedIPs: []string{"10.0.0.0/8"}}
+	if len(tokens) != 1 || !equalTokens(tokens[0], wantToken) {
+		t.Errorf("parseConfigFile() tokens = %+v, want %+v", tokens, wantToken)
+	}
+}
+
+func TestParseConfigFileProblems(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string // Expected problems, in order
+	}{
+		{
+			name:    "unknown key",
+			content: "listener:\n  port: 9012\n  prot: 9013\n",
+			want:    []string{"line 3: field prot not found in type struct"},
+		},
+		{
+			name:    "unknown section",
+			content: "listener:\n  port: 9012\n\nmetrics:\n  enabled: true\n",
+			want:    []string{"line 4: field metrics not found in type env.configFile"},
+		},
+		{
+			name:    "wrong type",
+			content: "listener:\n  port: http\n",
+			want:    []string{"line 2: cannot unmarshal !!str `http` into int"},
+		},
+		{
+			name:    "port out of range",
+			content: "listener:\n  port: 70000\n",
+			want:    []string{"line 2: listener.port: must be between 1 and 65535"},
+		},
+		{
+			name:    "token hash too short",
+			content: "auth:\n  tokens:\n    - name: dashboard\n      sha256: " + testTokenHash[:60] + "\n      scopes: [admin]\n",
+			want:    []string{`line 3: auth.tokens: token "dashboard": hash must be a hex encoded SHA-256 digest`},
+		},
+		{
+			name:    "unknown token scope",
+			content: "auth:\n  tokens:\n    - name: dashboard\n      sha256: " + testTokenHash + "\n      scopes: [sysinfo:read, write]\n",
+			want:    []string{`line 3: auth.tokens: token "dashboard": unknown scope "write"`},
+		},
+		{
+			name:    "malformed whitelist entry",
+			content: "auth:\n  whitelist_ips:\n    - 192.168.1.0/24\n    - 192.168.1.300\n",
+			want:    []string{`line 4: auth.whitelist_ips: invalid IP or CIDR "192.168.1.300"`},
+		},
+		{
+			name:    "malformed trusted proxy",
+			content: "auth:\n  trusted_proxies: [10.0.0.0/40]\n",
+			want:    []string{`line 2: auth.trusted_proxies: invalid IP or CIDR "10.0.0.0/40"`},
+		},
+		{
+			name:    "problems sorted by line",
+			content: "collectors:\n  interval: often\n  disabled: [gpu]\nlisteners:\n  port: 1\nlistener:\n  port: 0\n",
+			want: []string{
+				`line 2: collectors.interval: invalid interval "often"`,
+				`line 3: collectors.disabled: unknown collector "gpu"`,
+				"line 4: field listeners not found",
+				"line 7: listener.port: must be between 1 and 65535",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, tt.content)
+			_, _, err := parseConfigFile(path)
+			if err == nil {
+				t.Fatal("parseConfigFile() error = nil")
+			}
+
+			header, problems, _ := strings.Cut(err.Error(), "\n  ")
+			if header != "invalid config file "+path+":" {
+				t.Errorf("error header = %q", header)
+			}
+			got := strings.Split(problems, "\n  ")
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d problems, want %d:\n%s", len(got), len(tt.want), err)
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(got[i], want) {
+					t.Errorf("problem %d = %q, want it to start with %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseConfigFileSyntaxError(t *testing.T) {
+	path := writeConfigFile(t, "listener:\n  port: [9012\n")
+	if _, _, err := parseConfigFile(path); err == nil || !strings.HasPrefix(err.Error(), "invalid config file "+path+": yaml: line ") {
+		t.Errorf("parseConfigFile() error = %v, want a YAML syntax error with its line", err)
+	}
+}
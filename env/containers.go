@@ -0,0 +1,39 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"log"
+)
+
+// buildContainerLabelFilters returns the container labels to include and to exclude
+func buildContainerLabelFilters(c *config) ([]string, []string) {
+	var included, excluded []string
+
+	// Only report containers with one of the included labels if specified
+	if c.containerIncludeLabels != "" {
+		included = splitList(c.containerIncludeLabels)
+		log.Printf("Included container labels: %v", included)
+	}
+
+	// Skip containers with any of the excluded labels
+	if c.containerExcludeLabels != "" {
+		excluded = splitList(c.containerExcludeLabels)
+		log.Printf("Excluded container labels: %v", excluded)
+	}
+
+	return included, excluded
+}
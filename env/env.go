@@ -22,61 +22,183 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strconv"
+	"time"
 )
 
-// Variables to hold configuration
-var (
+// config holds the configuration values resolved from flags, environment variables, the .env file and the config file
+type config struct {
+	configPath                string                     // Path to the YAML config file
 	secretToken               string                     // Bearer token for API authentication
+	tokensFile                string                     // Path to the file holding named API tokens
 	port                      string                     // Server port number
 	ignoreMountpoints         string                     // Comma-separated list of mountpoints to ignore
 	whitelistedIPs            string                     // Comma-separated list of whitelisted IPs
+	trustedProxies            string                     // Comma-separated list of trusted reverse proxy IPs
+	trustedProxyHeader        string                     // Header trusted proxies pass the client address in
 	overrideIgnoreMountpoints string                     // Comma-separated list to override default ignored mountpoints
+	ignoreInterfaces          string                     // Comma-separated list of network interface patterns to ignore
+	includeInterfaces         string                     // Comma-separated list of network interface patterns to include
 	thermalZone               int                        // Path to thermal zone for temperature monitoring (LINUX ONLY)
+	tlsCert                   string                     // Path to the TLS certificate
+	tlsKey                    string                     // Path to the TLS private key
+	tlsClientCA               string                     // Path to the CA bundle used to verify client certificates
+	tlsClientAuth             string                     // Client certificate mode: none, require or cert
+	tlsSelfSigned             bool                       // Generate a self-signed certificate if none exists
+	collectInterval           time.Duration              // Interval between background collections, 0 collects per request
+	zfsInterval               time.Duration              // Interval between ZFS usage refreshes (LINUX ONLY)
+	cgroupAware               bool                       // Report memory and CPU against the agent's cgroup limits (LINUX ONLY)
+	topProcesses              int                        // Number of processes listed by CPU and by memory
+	redactCommandLines        bool                       // Only report the executable of process command lines
+	containerSocket           string                     // Unix socket of the Docker or Podman API
+	containerIncludeLabels    string                     // Comma-separated list of container labels to include
+	containerExcludeLabels    string                     // Comma-separated list of container labels to exclude
 	whitelistOnly             bool                       // Disable default IP local connection whitelist
-	showHelp                  bool                       // Show help message
-	appVersion                string                     // Application version, set by build process
+	disableMetrics            bool                       // Disable the Prometheus metrics endpoint
+	extensionTemplatesDir     string                     // Directory of templates overriding the Glance extension templates
+	streamMinInterval         time.Duration              // Shortest interval a stream client can request
+	streamMaxInterval         time.Duration              // Longest interval a stream client can request
+	streamMaxClients          int                        // Maximum number of concurrent streams
+	historyMaxMemoryMB        int                        // Memory limit of the metrics history in megabytes
+	hostProc                  string                     // Path of the host's /proc (LINUX ONLY)
+	hostSys                   string                     // Path of the host's /sys (LINUX ONLY)
+	hostEtc                   string                     // Path of the host's /etc (LINUX ONLY)
+	hostRoot                  string                     // Path of the host's root filesystem (LINUX ONLY)
 	featureToggles            system.FeatureToggleStruct // Feature toggles
-	useSystemConfig           bool                       // Whether to use system configuration file
+}
+
+// Subcommands given as the first command line argument
+const (
+	CommandCheck = "check" // Print the effective configuration and host diagnostics
+	CommandOnce  = "once"  // Print system information once without starting the server
+)
+
+// Variables to hold configuration
+var (
+	command         string // Subcommand given on the command line, empty when serving
+	configError     error  // Configuration error found by the check subcommand
+	runOnce         bool   // Print system information once, same as the once subcommand
+	outputFormat    string // Output format of the once subcommand
+	flagValues      config // Values set by command line flags, and the defaults of the flags that were not set
+	showHelp        bool   // Show help message
+	appVersion      string // Application version, set by build process
+	useSystemConfig bool   // Whether to use system configuration file
 )
 
+// GetCommand returns the subcommand given on the command line, empty when serving
+func GetCommand() string {
+	return command
+}
+
+// GetConfigError returns the configuration error found by the check subcommand
+func GetConfigError() error {
+	return configError
+}
+
+// GetOutputFormat returns the output format of the once subcommand
+func GetOutputFormat() string {
+	return outputFormat
+}
+
+// GetEnvFile returns the path of the .env file in use
+func GetEnvFile() string {
+	return active.Load().envFile
+}
+
 // GetSecretToken returns the configured secret token
 func GetSecretToken() string {
-	return secretToken
+	return active.Load().values.secretToken
 }
 
 // GetPort returns the configured server port
 func GetPort() string {
-	return port
+	return active.Load().values.port
+}
+
+// GetMetricsEnabled returns whether the Prometheus metrics endpoint is enabled
+func GetMetricsEnabled() bool {
+	return !active.Load().values.disableMetrics
+}
+
+// GetExtensionTemplatesDir returns the directory of templates overriding the Glance extension templates
+func GetExtensionTemplatesDir() string {
+	return active.Load().values.extensionTemplatesDir
+}
+
+// GetCollectInterval returns the configured background collection interval
+func GetCollectInterval() time.Duration {
+	return active.Load().values.collectInterval
 }
 
 // showUsage displays help information
 func showUsage() {
 	fmt.Printf("Glance Agent %s - Linux System Monitoring Agent\n\n", appVersion)
 	fmt.Println("USAGE:")
-	fmt.Printf("  %s [OPTIONS]\n\n", filepath.Base(os.Args[0]))
+	fmt.Printf("  %s [OPTIONS]\n", filepath.Base(os.Args[0]))
+	fmt.Printf("  %s check [OPTIONS]   Print the effective configuration and host diagnostics\n", filepath.Base(os.Args[0]))
+	fmt.Printf("  %s once [OPTIONS]    Print system information once and exit, no token is required\n\n", filepath.Base(os.Args[0]))
 	fmt.Println("OPTIONS:")
 	flag.PrintDefaults()
 	fmt.Println("\nENVIRONMENT VARIABLES:")
-	fmt.Println("  SECRET_TOKEN                   Bearer token for API authentication")
+	fmt.Println("  CONFIG_FILE                    Path to a YAML config file, overridden by flags and environment variables")
+	fmt.Println("  SECRET_TOKEN                   Bearer token for API authentication, granted every scope")
+	fmt.Println("  TOKENS_FILE                    Path to a file of named API tokens with scopes and IP restrictions")
 	fmt.Println("  PORT                           Server port number (default: 9012)")
 	fmt.Println("  IGNORE_MOUNTPOINTS             Comma-separated additional mountpoints to ignore")
 	fmt.Println("  WHITELIST_IPS                  Comma-separated additional Whitelist IPs")
+	fmt.Println("  TRUSTED_PROXIES                Comma-separated reverse proxy IPs/CIDRs whose forwarded headers are trusted")
+	fmt.Println("  TRUSTED_PROXY_HEADER           Header trusted proxies set the client address in: X-Forwarded-For, Forwarded or X-Real-IP")
+	fmt.Println("                                 (default: X-Forwarded-For)")
 	fmt.Println("  OVERRIDE_IGNORED_MOUNTPOINTS   Comma-separated override for default ignored mountpoints")
+	fmt.Println("  IGNORE_INTERFACES              Comma-separated network interface patterns to ignore (e.g. veth*,docker*)")
+	fmt.Println("  INCLUDE_INTERFACES             Comma-separated network interface patterns to include, all others are ignored")
 	fmt.Println("  THERMAL_ZONE                   Override the thermal zone for temperature monitoring (Linux only).")
 	fmt.Println("                                 Zones can be listed in /sys/class/thermal/")
+	fmt.Println("  TLS_CERT                       Path to the PEM encoded TLS certificate, enables HTTPS")
+	fmt.Println("  TLS_KEY                        Path to the PEM encoded TLS private key")
+	fmt.Println("  TLS_SELF_SIGNED                Generate and persist a self-signed certificate if none exists (default: false)")
+	fmt.Println("  TLS_CLIENT_CA                  Path to a PEM encoded CA bundle used to verify client certificates (mTLS)")
+	fmt.Println("  TLS_CLIENT_AUTH                Client certificate mode: none, require or cert (default: require with TLS_CLIENT_CA)")
+	fmt.Println("                                 require needs a client certificate and a token, cert accepts either")
+	fmt.Println("  COLLECT_INTERVAL               Interval between background collections (default: 10s)")
+	fmt.Println("                                 Set to 0 to collect system information on every request")
+	fmt.Println("  ZFS_INTERVAL                   Interval between ZFS usage refreshes (default: 1m, Linux only)")
+	fmt.Println("  CGROUP_AWARE                   Report memory and CPU against the limits of the agent's cgroup (default: false, Linux only)")
 	fmt.Println("  DISABLE_CPU_LOAD               Disable CPU load monitoring (default: false)")
+	fmt.Println("  DISABLE_CPU_USAGE              Disable CPU utilisation monitoring (default: false)")
 	fmt.Println("  DISABLE_TEMPERATURE            Disable temperature monitoring (default: false)")
+	fmt.Println("  DISABLE_SENSORS                Disable thermal zone and hardware sensor monitoring (default: false)")
 	fmt.Println("  DISABLE_MEMORY                 Disable memory monitoring (default: false)")
 	fmt.Println("  DISABLE_SWAP                   Disable swap monitoring (default: false)")
 	fmt.Println("  DISABLE_DISK                   Disable disk monitoring (default: false)")
+	fmt.Println("  DISABLE_DISK_IO                Disable disk I/O monitoring (default: false)")
 	fmt.Println("  DISABLE_HOST                   Disable host information (default: false)")
+	fmt.Println("  DISABLE_NETWORK                Disable network monitoring (default: false)")
+	fmt.Println("  DISABLE_PRESSURE               Disable pressure stall information (default: false)")
+	fmt.Println("  DISABLE_PROCESSES              Disable top process monitoring (default: false)")
+	fmt.Println("  TOP_PROCESSES                  Number of processes listed by CPU and by memory (default: 5)")
+	fmt.Println("  REDACT_COMMAND_LINES           Only report the executable of process command lines (default: true)")
+	fmt.Println("  DISABLE_CONTAINERS             Disable container monitoring (default: false)")
+	fmt.Println("  DOCKER_SOCKET                  Unix socket of the Docker or Podman API (default: /var/run/docker.sock)")
+	fmt.Println("  CONTAINER_INCLUDE_LABELS       Comma-separated container labels (key or key=value) to include, all others are ignored")
+	fmt.Println("  CONTAINER_EXCLUDE_LABELS       Comma-separated container labels (key or key=value) to ignore")
+	fmt.Println("  DISABLE_METRICS                Disable the Prometheus metrics endpoint (default: false)")
+	fmt.Println("  STREAM_MIN_INTERVAL            Shortest interval a client can request from /api/sysinfo/stream (default: 1s)")
+	fmt.Println("  STREAM_MAX_INTERVAL            Longest interval a client can request from /api/sysinfo/stream (default: 5m)")
+	fmt.Println("  STREAM_MAX_CLIENTS             Maximum number of concurrent streams, 0 disables streaming (default: 10)")
+	fmt.Println("  HISTORY_MAX_MEMORY_MB          Memory limit of the metrics history in megabytes, 0 disables it (default: 16)")
+	fmt.Println("  EXTENSION_TEMPLATES_DIR        Directory of *.html templates overriding the Glance extension widget templates")
+	fmt.Println("  HOST_ROOT                      Path the host's root filesystem is mounted on, e.g. /host (default: /, Linux only)")
+	fmt.Println("  HOST_PROC                      Path of the host's /proc (default: HOST_ROOT/proc, Linux only)")
+	fmt.Println("  HOST_SYS                       Path of the host's /sys (default: HOST_ROOT/sys, Linux only)")
+	fmt.Println("  HOST_ETC                       Path of the host's /etc (default: HOST_ROOT/etc, Linux only)")
 	fmt.Println("  WHITELIST_ONLY                 Disables the default IP local connection whitelist (default: false)")
 	fmt.Println("\nEXAMPLES:")
 	fmt.Printf("  %s -token mytoken -port 8080\n", filepath.Base(os.Args[0]))
 	fmt.Printf("  SECRET_TOKEN=mytoken %s\n", filepath.Base(os.Args[0]))
 	fmt.Printf("  %s -token mytoken -disable-temp -disable-swap\n", filepath.Base(os.Args[0]))
+	fmt.Printf("  %s check --use-system-config\n", filepath.Base(os.Args[0]))
+	fmt.Printf("  %s once -format text\n", filepath.Base(os.Args[0]))
 	fmt.Println("\n.ENV FILE:")
 	fmt.Println("  The application will automatically load a .env file from the same directory as the binary.")
 	fmt.Println("  You can set the same configuration options in the .env file as environment variables.")
@@ -88,6 +210,10 @@ func showUsage() {
 	fmt.Println("  OVERRIDE_IGNORED_MOUNTPOINTS=/snap,/boot/efi")
 	fmt.Println("  DISABLE_CPU_LOAD=true")
 	fmt.Println("  DISABLE_TEMPERATURE=true")
+	fmt.Println("\nCONFIG FILE:")
+	fmt.Println("  Structured configuration can be loaded from a YAML file with -config or CONFIG_FILE.")
+	fmt.Println("  With --use-system-config /etc/glance-agent/config.yaml is used if CONFIG_FILE is not set.")
+	fmt.Println("  Precedence: flags > environment variables > .env file > config file")
 	fmt.Println("")
 	fmt.Printf("Glance Agent Copyright (C) Ava Glass <SuperNinja_4965> \nThis program comes as is with ABSOLUTELY NO WARRANTY. \nThis is free software, and you are welcome to redistribute it \nunder certain conditions; For details please visit https://github.com/SuperNinja-4965/Glance-Agent/blob/main/LICENSE.\n\n")
 }
@@ -96,28 +222,74 @@ func LoadConfig(version string) {
 	appVersion = version // Set the application version
 
 	// Define command line flags
-	flag.StringVar(&secretToken, "token", "", "Bearer token for API authentication (required)")
-	flag.StringVar(&port, "port", "9012", "Server port number")
-	flag.StringVar(&ignoreMountpoints, "ignore-mounts", "", "Comma-separated list of additional mountpoints to ignore")
-	flag.StringVar(&whitelistedIPs, "whitelist-ip", "", "Comma-separated list of IPs to allow")
-	flag.StringVar(&overrideIgnoreMountpoints, "override-mounts", "", "Comma-separated list to override default ignored mountpoints")
-	flag.IntVar(&thermalZone, "thermal-zone", -1, "ID of the thermal zone for temperature monitoring (Linux only)")
-	flag.BoolVar(&whitelistOnly, "whitelist-only", false, "Disable default IP local connection whitelist")
+	flag.StringVar(&flagValues.configPath, "config", "", "Path to a YAML config file")
+	flag.StringVar(&flagValues.secretToken, "token", "", "Bearer token for API authentication (required unless -tokens-file is set)")
+	flag.StringVar(&flagValues.tokensFile, "tokens-file", "", "Path to a file of named API tokens with scopes and IP restrictions")
+	flag.StringVar(&flagValues.port, "port", "9012", "Server port number")
+	flag.StringVar(&flagValues.ignoreMountpoints, "ignore-mounts", "", "Comma-separated list of additional mountpoints to ignore")
+	flag.StringVar(&flagValues.whitelistedIPs, "whitelist-ip", "", "Comma-separated list of IPs to allow")
+	flag.StringVar(&flagValues.trustedProxies, "trusted-proxies", "", "Comma-separated list of reverse proxy IPs/CIDRs whose forwarded headers are trusted")
+	flag.StringVar(&flagValues.trustedProxyHeader, "trusted-proxy-header", "X-Forwarded-For", "Header trusted proxies set the client address in: X-Forwarded-For, Forwarded or X-Real-IP")
+	flag.StringVar(&flagValues.overrideIgnoreMountpoints, "override-mounts", "", "Comma-separated list to override default ignored mountpoints")
+	flag.StringVar(&flagValues.ignoreInterfaces, "ignore-interfaces", "", "Comma-separated list of network interface patterns to ignore")
+	flag.StringVar(&flagValues.includeInterfaces, "include-interfaces", "", "Comma-separated list of network interface patterns to include")
+	flag.IntVar(&flagValues.thermalZone, "thermal-zone", -1, "ID of the thermal zone for temperature monitoring (Linux only)")
+	flag.StringVar(&flagValues.tlsCert, "tls-cert", "", "Path to the PEM encoded TLS certificate, enables HTTPS")
+	flag.StringVar(&flagValues.tlsKey, "tls-key", "", "Path to the PEM encoded TLS private key")
+	flag.BoolVar(&flagValues.tlsSelfSigned, "tls-self-signed", false, "Generate and persist a self-signed certificate if none exists")
+	flag.StringVar(&flagValues.tlsClientCA, "tls-client-ca", "", "Path to a PEM encoded CA bundle used to verify client certificates")
+	flag.StringVar(&flagValues.tlsClientAuth, "tls-client-auth", "", "Client certificate mode: none, require or cert")
+	flag.DurationVar(&flagValues.collectInterval, "collect-interval", 10*time.Second, "Interval between background collections, 0 collects on every request")
+	flag.DurationVar(&flagValues.zfsInterval, "zfs-interval", time.Minute, "Interval between ZFS usage refreshes (Linux only)")
+	flag.BoolVar(&flagValues.cgroupAware, "cgroup-aware", false, "Report memory and CPU against the limits of the agent's cgroup (Linux only)")
+	flag.BoolVar(&flagValues.whitelistOnly, "whitelist-only", false, "Disable default IP local connection whitelist")
 	flag.BoolVar(&showHelp, "help", false, "Show the help message")
-
-	flag.BoolVar(&featureToggles.DisableCPULoad, "disable-cpu", false, "Disable CPU load monitoring")
-	flag.BoolVar(&featureToggles.DisableTemperature, "disable-temp", false, "Disable temperature monitoring")
-	flag.BoolVar(&featureToggles.DisableMemory, "disable-memory", false, "Disable memory monitoring")
-	flag.BoolVar(&featureToggles.DisableSwap, "disable-swap", false, "Disable swap monitoring")
-	flag.BoolVar(&featureToggles.DisableDisk, "disable-disk", false, "Disable disk monitoring")
-	flag.BoolVar(&featureToggles.DisableHost, "disable-host", false, "Disable host information")
+	flag.BoolVar(&runOnce, "once", false, "Print system information once and exit, same as the once subcommand")
+	flag.StringVar(&outputFormat, "format", "json", "Output format of the once subcommand: json, text or prometheus")
+
+	flag.BoolVar(&flagValues.featureToggles.DisableCPULoad, "disable-cpu", false, "Disable CPU load monitoring")
+	flag.BoolVar(&flagValues.featureToggles.DisableCPUUsage, "disable-cpu-usage", false, "Disable CPU utilisation monitoring")
+	flag.BoolVar(&flagValues.featureToggles.DisableTemperature, "disable-temp", false, "Disable temperature monitoring")
+	flag.BoolVar(&flagValues.featureToggles.DisableSensors, "disable-sensors", false, "Disable thermal zone and hardware sensor monitoring")
+	flag.BoolVar(&flagValues.featureToggles.DisableMemory, "disable-memory", false, "Disable memory monitoring")
+	flag.BoolVar(&flagValues.featureToggles.DisableSwap, "disable-swap", false, "Disable swap monitoring")
+	flag.BoolVar(&flagValues.featureToggles.DisableDisk, "disable-disk", false, "Disable disk monitoring")
+	flag.BoolVar(&flagValues.featureToggles.DisableDiskIO, "disable-disk-io", false, "Disable disk I/O monitoring")
+	flag.BoolVar(&flagValues.featureToggles.DisableHost, "disable-host", false, "Disable host information")
+	flag.BoolVar(&flagValues.featureToggles.DisableNetwork, "disable-network", false, "Disable network monitoring")
+	flag.BoolVar(&flagValues.featureToggles.DisablePressure, "disable-pressure", false, "Disable pressure stall information")
+	flag.BoolVar(&flagValues.featureToggles.DisableProcesses, "disable-processes", false, "Disable top process monitoring")
+	flag.IntVar(&flagValues.topProcesses, "top-processes", 5, "Number of processes listed by CPU and by memory")
+	flag.BoolVar(&flagValues.redactCommandLines, "redact-cmdline", true, "Only report the executable of process command lines")
+	flag.BoolVar(&flagValues.featureToggles.DisableContainers, "disable-containers", false, "Disable container monitoring")
+	flag.StringVar(&flagValues.containerSocket, "docker-socket", "/var/run/docker.sock", "Unix socket of the Docker or Podman API")
+	flag.StringVar(&flagValues.containerIncludeLabels, "include-container-labels", "", "Comma-separated list of container labels (key or key=value) to include")
+	flag.StringVar(&flagValues.containerExcludeLabels, "exclude-container-labels", "", "Comma-separated list of container labels (key or key=value) to ignore")
+	flag.BoolVar(&flagValues.disableMetrics, "disable-metrics", false, "Disable the Prometheus metrics endpoint")
+	flag.DurationVar(&flagValues.streamMinInterval, "stream-min-interval", time.Second, "Shortest interval a client can request from the live metrics stream")
+	flag.DurationVar(&flagValues.streamMaxInterval, "stream-max-interval", 5*time.Minute, "Longest interval a client can request from the live metrics stream")
+	flag.IntVar(&flagValues.streamMaxClients, "stream-max-clients", 10, "Maximum number of concurrent live metrics streams, 0 disables streaming")
+	flag.IntVar(&flagValues.historyMaxMemoryMB, "history-max-memory", 16, "Memory limit of the metrics history in megabytes, 0 disables it")
+	flag.StringVar(&flagValues.extensionTemplatesDir, "extension-templates", "", "Directory of templates overriding the Glance extension widget templates")
+	flag.StringVar(&flagValues.hostRoot, "host-root", "", "Path the host's root filesystem is mounted on (Linux only)")
+	flag.StringVar(&flagValues.hostProc, "host-proc", "", "Path of the host's /proc (Linux only)")
+	flag.StringVar(&flagValues.hostSys, "host-sys", "", "Path of the host's /sys (Linux only)")
+	flag.StringVar(&flagValues.hostEtc, "host-etc", "", "Path of the host's /etc (Linux only)")
 	flag.BoolVar(&useSystemConfig, "use-system-config", false, "Use system configuration file if available (/etc/glance-agent/config.env)")
 
 	// Custom usage function
 	flag.Usage = showUsage
 
-	// Parse command line flags
-	flag.Parse()
+	// Parse command line flags, after the subcommand if one is given
+	args := os.Args[1:]
+	if len(args) > 0 && (args[0] == CommandCheck || args[0] == CommandOnce) {
+		command = args[0]
+		args = args[1:]
+	}
+	_ = flag.CommandLine.Parse(args) // Invalid flags exit the program
+	if runOnce && command == "" {
+		command = CommandOnce
+	}
 
 	// Show help and exit
 	if showHelp {
@@ -125,159 +297,34 @@ func LoadConfig(version string) {
 		os.Exit(0)
 	}
 
-	// Load .env file if it exists
-	loadEnvFile()
-
-	// Set configuration from environment variables and command line flags
-	configureFromSources()
-
-	// Validate required configuration
-	if secretToken == "" {
-		log.Fatal("SECRET_TOKEN is required. Set via environment variable, .env file, or -token flag")
-	}
-
-	// Configure mountpoints
-	configureMountpoints()
-	// configure IP whitelist
-	configureWhitelistIPs()
-
-	if runtime.GOOS == "linux" {
-		// Set thermal zone for CPU temperature monitoring
-		system.SetCPUThermalZone(thermalZone)
-	} else {
-		if thermalZone != 0 {
-			log.Printf("Thermal zone is only applicable on Linux. Ignoring value %d", thermalZone)
-		}
-	}
-
-	// Set what features are enabled/disabled
-	system.SetFeatureToggles(featureToggles)
-
-}
-
-// configureFromSources sets configuration from multiple sources with precedence:
-// 1. Command line flags (highest priority)
-// 2. Environment variables
-// 3. .env file (lowest priority)
-func configureFromSources() {
-	// Check if flags were actually set by user
-	tokenSet := false
-	portSet := false
-	whitelistOnlySet := false
-	cpuFlagSet := false
-	tempFlagSet := false
-	memoryFlagSet := false
-	swapFlagSet := false
-	diskFlagSet := false
-	hostFlagSet := false
-	thermalZoneSet := false
-
-	flag.Visit(func(f *flag.Flag) {
-		switch f.Name {
-		case "token":
-			tokenSet = true
-		case "port":
-			portSet = true
-		case "whitelist-only":
-			whitelistOnlySet = true
-		case "disable-cpu":
-			cpuFlagSet = true
-		case "disable-temp":
-			tempFlagSet = true
-		case "disable-memory":
-			memoryFlagSet = true
-		case "disable-swap":
-			swapFlagSet = true
-		case "disable-disk":
-			diskFlagSet = true
-		case "disable-host":
-			hostFlagSet = true
-		case "thermal-zone":
-			thermalZoneSet = true
-		}
-	})
-
-	// SECRET_TOKEN: CLI flag > env var
-	if !tokenSet {
-		if envToken := os.Getenv("SECRET_TOKEN"); envToken != "" {
-			secretToken = envToken
-		}
-	}
-
-	// PORT: CLI flag > env var > default
-	if !portSet {
-		if envPort := os.Getenv("PORT"); envPort != "" {
-			port = envPort
-		}
-	}
-
-	// IGNORE_MOUNTPOINTS: CLI flag > env var
-	if ignoreMountpoints == "" {
-		ignoreMountpoints = os.Getenv("IGNORE_MOUNTPOINTS")
-	}
-
-	// WHITELIST_IPS: CLI FLAG > env var
-	if whitelistedIPs == "" {
-		whitelistedIPs = os.Getenv("WHITELIST_IPS")
-	}
-
-	// OVERRIDE_IGNORED_MOUNTPOINTS: CLI flag > env var
-	if overrideIgnoreMountpoints == "" {
-		overrideIgnoreMountpoints = os.Getenv("OVERRIDE_IGNORED_MOUNTPOINTS")
-	}
-
-	// WHITELIST_ONLY: CLI FLAG > env var
-	if !whitelistOnlySet {
-		if envVal := os.Getenv("WHITELIST_ONLY"); envVal != "" {
-			whitelistOnly = envVal == "true"
-		}
-	}
-
-	// Feature toggles: CLI flag > env var > default (true)
-	if !cpuFlagSet {
-		if envVal := os.Getenv("DISABLE_CPU_LOAD"); envVal != "" {
-			featureToggles.DisableCPULoad = envVal == "true"
-		}
+	// Read every configuration source and apply the result
+	loaded, err := loadConfig()
+	if command == CommandCheck {
+		// The check subcommand reports errors itself, so apply whatever could be resolved
+		configError = strictError(loaded, err)
+		applyConfig(loaded)
+		return
 	}
-
-	if !tempFlagSet {
-		if envVal := os.Getenv("DISABLE_TEMPERATURE"); envVal != "" {
-			featureToggles.DisableTemperature = envVal == "true"
-		}
-	}
-
-	if !memoryFlagSet {
-		if envVal := os.Getenv("DISABLE_MEMORY"); envVal != "" {
-			featureToggles.DisableMemory = envVal == "true"
-		}
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	if !swapFlagSet {
-		if envVal := os.Getenv("DISABLE_SWAP"); envVal != "" {
-			featureToggles.DisableSwap = envVal == "true"
-		}
+	for _, problem := range loaded.invalid {
+		log.Printf("Warning: %v, using the default", problem)
 	}
+	applyConfig(loaded)
+}
 
-	if !diskFlagSet {
-		if envVal := os.Getenv("DISABLE_DISK"); envVal != "" {
-			featureToggles.DisableDisk = envVal == "true"
-		}
+// parseInterval parses a duration such as "30s" or "5m", a bare number is treated as seconds
+func parseInterval(value string) (time.Duration, error) {
+	interval, err := time.ParseDuration(value)
+	if seconds, convErr := strconv.Atoi(value); convErr == nil {
+		interval, err = time.Duration(seconds)*time.Second, nil
 	}
-
-	if !hostFlagSet {
-		if envVal := os.Getenv("DISABLE_HOST"); envVal != "" {
-			featureToggles.DisableHost = envVal == "true"
-		}
+	if err != nil {
+		return 0, err
 	}
-
-	if !thermalZoneSet {
-		if envVal := os.Getenv("THERMAL_ZONE"); envVal != "" {
-			var err error
-			thermalZone, err = strconv.Atoi(envVal)
-			if err != nil {
-				log.Printf("Invalid THERMAL_ZONE value: %s.", envVal)
-				thermalZone = -1 // Default to -1 if conversion fails. (autodetect)
-			}
-		}
+	if interval < 0 {
+		return 0, fmt.Errorf("interval must not be negative")
 	}
+	return interval, nil
 }
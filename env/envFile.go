@@ -23,13 +23,17 @@ import (
 	"github.com/joho/godotenv"
 )
 
-// loadEnvFile loads environment variables from .env file in the same directory as the binary
-func loadEnvFile() {
+// readEnvFile reads the .env file in the same directory as the binary
+// The values are kept apart from the process environment so real environment variables take precedence.
+// Returns the path of the file, which is watched for changes even if it does not exist yet, and its values.
+func readEnvFile() (string, map[string]string) {
+	values := map[string]string{}
+
 	// Get the directory where the binary is located
 	execPath, err := os.Executable()
 	if err != nil {
 		log.Printf("Warning: Could not determine executable path: %v", err)
-		return
+		return "", values
 	}
 
 	// Get the directory containing the executable
@@ -40,12 +44,13 @@ func loadEnvFile() {
 	if useSystemConfig {
 		systemEnv := "/etc/glance-agent/config.env"
 		if _, err := os.Stat(systemEnv); err == nil {
-			if err := godotenv.Load(systemEnv); err != nil {
+			if systemValues, err := godotenv.Read(systemEnv); err != nil {
 				log.Printf("Warning: Error loading system config '%s': %v", systemEnv, err)
 			} else {
+				values = systemValues
 				log.Printf("Loaded configuration from %s", systemEnv)
 			}
-			return
+			return systemEnv, values
 		}
 		// If not present, continue to check local .env
 		log.Printf("System config not found at %s; falling back to .env", systemEnv)
@@ -53,13 +58,15 @@ func loadEnvFile() {
 
 	// Check if .env file exists in the same directory as the binary
 	if _, err := os.Stat(envFile); err == nil {
-		if err := godotenv.Load(envFile); err != nil {
+		if fileValues, err := godotenv.Read(envFile); err != nil {
 			log.Printf("Warning: Error loading .env file '%s': %v", envFile, err)
 		} else {
+			values = fileValues
 			log.Printf("Loaded configuration from %s", envFile)
 		}
 	} else {
 		// Silently continue if .env file doesn't exist
 		log.Printf("No .env file found at %s", envFile)
 	}
+	return envFile, values
 }
@@ -0,0 +1,68 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"fmt"
+	"glance-agent/system"
+	"log"
+	"path/filepath"
+	"runtime"
+)
+
+// buildHostPaths returns where the host's filesystems are read from
+// HOST_PROC, HOST_SYS and HOST_ETC default to the matching directory below HOST_ROOT.
+func buildHostPaths(c *config) (system.HostPaths, error) {
+	if c.hostRoot == "" && c.hostProc == "" && c.hostSys == "" && c.hostEtc == "" {
+		return system.HostPaths{}, nil // Read the filesystems of the system the agent runs on
+	}
+	if runtime.GOOS != "linux" {
+		log.Println("HOST_ROOT, HOST_PROC, HOST_SYS and HOST_ETC are only applicable on Linux. Ignoring them")
+		return system.HostPaths{}, nil
+	}
+
+	root := "/"
+	if c.hostRoot != "" {
+		root = filepath.Clean(c.hostRoot)
+	}
+	hostPaths := system.HostPaths{
+		Root: root,
+		Proc: hostPath(c.hostProc, root, "proc"),
+		Sys:  hostPath(c.hostSys, root, "sys"),
+		Etc:  hostPath(c.hostEtc, root, "etc"),
+	}
+
+	for _, path := range []struct{ name, value string }{
+		{"HOST_ROOT", hostPaths.Root},
+		{"HOST_PROC", hostPaths.Proc},
+		{"HOST_SYS", hostPaths.Sys},
+		{"HOST_ETC", hostPaths.Etc},
+	} {
+		if !filepath.IsAbs(path.value) {
+			return system.HostPaths{}, fmt.Errorf("%s must be an absolute path, got %q", path.name, path.value)
+		}
+	}
+	log.Printf("Reading host filesystems from root %s, proc %s, sys %s, etc %s", hostPaths.Root, hostPaths.Proc, hostPaths.Sys, hostPaths.Etc)
+	return hostPaths, nil
+}
+
+// hostPath returns the configured path, or the directory of that name below the host root
+func hostPath(configured, root, dir string) string {
+	if configured != "" {
+		return filepath.Clean(configured)
+	}
+	return filepath.Join(root, dir)
+}
@@ -0,0 +1,49 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"log"
+	"strings"
+)
+
+// buildInterfaceFilters returns the network interface patterns to ignore and to include
+func buildInterfaceFilters(c *config) ([]string, []string) {
+	var ignored, included []string
+
+	// Add extra interfaces to ignore from configuration
+	if c.ignoreInterfaces != "" {
+		ignored = splitList(c.ignoreInterfaces)
+		log.Printf("Added ignored interfaces: %v", ignored)
+	}
+
+	// Only report the included interfaces if specified
+	if c.includeInterfaces != "" {
+		included = splitList(c.includeInterfaces)
+		log.Printf("Included interfaces: %v", included)
+	}
+
+	return ignored, included
+}
+
+// splitList splits a comma-separated list and trims whitespace around each entry
+func splitList(list string) []string {
+	entries := strings.Split(list, ",")
+	for i, entry := range entries {
+		entries[i] = strings.TrimSpace(entry)
+	}
+	return entries
+}
@@ -15,31 +15,24 @@ package env
 // You should have received a copy of the GNU General Public License
 // along with this program. If not, see <https://www.gnu.org/licenses/>.
 
-import (
-	"glance-agent/system"
-	"log"
-	"strings"
-)
+import "log"
+
+// buildIgnoredMountpoints returns the mountpoints to ignore in addition to the defaults
+// OVERRIDE_IGNORED_MOUNTPOINTS replaces IGNORE_MOUNTPOINTS when both are set.
+func buildIgnoredMountpoints(c *config) []string {
+	var mountpoints []string
 
-// configureMountpoints sets up mountpoint ignore lists
-func configureMountpoints() {
 	// Add extra mountpoints from configuration
-	if ignoreMountpoints != "" {
-		mountpoints := strings.Split(ignoreMountpoints, ",")
-		for i, mp := range mountpoints {
-			mountpoints[i] = strings.TrimSpace(mp)
-		}
-		system.AddIgnoredMountpoints(mountpoints)
+	if c.ignoreMountpoints != "" {
+		mountpoints = splitList(c.ignoreMountpoints)
 		log.Printf("Added ignored mountpoints: %v", mountpoints)
 	}
 
 	// Override ignored mountpoints if specified
-	if overrideIgnoreMountpoints != "" {
-		mountpoints := strings.Split(overrideIgnoreMountpoints, ",")
-		for i, mp := range mountpoints {
-			mountpoints[i] = strings.TrimSpace(mp)
-		}
-		system.SetExtraIgnoredMountpoints(mountpoints)
+	if c.overrideIgnoreMountpoints != "" {
+		mountpoints = splitList(c.overrideIgnoreMountpoints)
 		log.Printf("Override ignored mountpoints: %v", mountpoints)
 	}
+
+	return mountpoints
 }
@@ -0,0 +1,291 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"glance-agent/certs"
+	"glance-agent/system"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// configPollInterval is how often the configuration files are checked for changes
+const configPollInterval = 2 * time.Second
+
+// Access holds the access control configuration
+type Access struct {
+	WhitelistIPs   []string // IPs and CIDR ranges allowed in addition to local networks
+	WhitelistOnly  bool     // Only allow the whitelisted IPs
+	TrustedProxies []string // Reverse proxies whose forwarded headers are trusted
+	ProxyHeader    string   // Header trusted proxies pass the client address in
+	Tokens         []Token  // API tokens
+}
+
+// loadedConfig is a resolved and validated configuration, applied as a whole
+type loadedConfig struct {
+	values   config            // Values resolved from the configuration sources
+	sources  map[string]string // Source of each value, keyed by environment variable name
+	access   Access            // Access control configuration
+	settings system.Settings   // Collector configuration
+	tls      certs.Options     // TLS configuration of the listener
+	stream   StreamOptions     // Limits of the live metrics stream
+	envFile  string            // Path of the .env file
+	invalid  []error           // Values that could not be parsed and were left at their default
+}
+
+// active holds the configuration in use
+var active atomic.Pointer[loadedConfig]
+
+// reloadMutex prevents a signal and a file change from reloading at the same time
+var reloadMutex sync.Mutex
+
+func init() {
+	active.Store(&loadedConfig{sources: map[string]string{}})
+}
+
+// GetAccess returns the access control configuration in use
+// The returned value is replaced, never modified, when the configuration is reloaded.
+func GetAccess() *Access {
+	return &active.Load().access
+}
+
+// GetTokens returns the configured API tokens
+func GetTokens() []Token {
+	return GetAccess().Tokens
+}
+
+// loadConfig reads every configuration source and validates the result without applying it
+// Every problem is collected so they can be reported together. On error the configuration
+// resolved from the remaining sources is returned alongside it for diagnostics.
+// Values that could not be parsed are not part of the error but listed in the invalid field,
+// as they only prevent a reload; see strictError.
+func loadConfig() (*loadedConfig, error) {
+	var problems []error
+	envFile, dotEnv := readEnvFile()
+
+	// Load the YAML config file if one is configured
+	configPath, configSource := resolveConfigPath(dotEnv)
+	var fileValues map[string]string
+	var fileTokens []Token
+	if configPath != "" {
+		var err error
+		if fileValues, fileTokens, err = parseConfigFile(configPath); err != nil {
+			problems = append(problems, err)
+		} else {
+			log.Printf("Loaded configuration from %s", configPath)
+		}
+	}
+
+	// Set configuration from environment variables and command line flags
+	values, sources, invalid := resolveSources(dotEnv, fileValues)
+	values.configPath = configPath
+	sources["CONFIG_FILE"] = configSource
+
+	// Configure API tokens, at least one is required
+	tokens, err := buildTokens(&values, fileTokens)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	// Configure TLS on the listener
+	tlsOptions, err := buildTLSOptions(&values)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	// Configure the live metrics stream
+	streamOptions, err := buildStreamOptions(&values)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	// Configure where the host's filesystems are read from
+	hostPaths, err := buildHostPaths(&values)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	// Configure which header of trusted proxies holds the client address
+	proxyHeader, err := buildProxyHeader(&values)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	// configure IP whitelist
+	whitelist, err := buildWhitelist(&values)
+	if err != nil {
+		problems = append(problems, err)
+	}
+
+	if values.topProcesses < 1 {
+		problems = append(problems, fmt.Errorf("TOP_PROCESSES must be at least 1, got %d", values.topProcesses))
+	}
+
+	if runtime.GOOS != "linux" && values.thermalZone >= 0 {
+		log.Printf("Thermal zone is only applicable on Linux. Ignoring value %d", values.thermalZone)
+	}
+	if runtime.GOOS != "linux" && values.cgroupAware {
+		log.Println("CGROUP_AWARE is only applicable on Linux. Ignoring it")
+	}
+
+	ignoredInterfaces, includedInterfaces := buildInterfaceFilters(&values)
+	includedContainerLabels, excludedContainerLabels := buildContainerLabelFilters(&values)
+	return &loadedConfig{
+		values:  values,
+		sources: sources,
+		access: Access{
+			WhitelistIPs:   whitelist,
+			WhitelistOnly:  values.whitelistOnly,
+			TrustedProxies: buildTrustedProxies(&values),
+			ProxyHeader:    proxyHeader,
+			Tokens:         tokens,
+		},
+		settings: system.Settings{
+			Features:                values.featureToggles,
+			IgnoredMountpoints:      buildIgnoredMountpoints(&values),
+			IgnoredInterfaces:       ignoredInterfaces,
+			IncludedInterfaces:      includedInterfaces,
+			CPUThermalZone:          values.thermalZone,
+			ZFSRefreshInterval:      values.zfsInterval,
+			HistoryMaxMemoryMB:      values.historyMaxMemoryMB,
+			HostPaths:               hostPaths,
+			CgroupAware:             values.cgroupAware,
+			TopProcesses:            values.topProcesses,
+			RedactCommandLines:      values.redactCommandLines,
+			ContainerSocket:         values.containerSocket,
+			IncludedContainerLabels: includedContainerLabels,
+			ExcludedContainerLabels: excludedContainerLabels,
+		},
+		tls:     tlsOptions,
+		stream:  streamOptions,
+		envFile: envFile,
+		invalid: invalid,
+	}, errors.Join(problems...)
+}
+
+// strictError returns the problems of a loaded configuration including the invalid values
+// The agent starts with invalid values left at their default, as it did before they were
+// reported, while a reload and the check subcommand treat them as errors.
+func strictError(loaded *loadedConfig, err error) error {
+	return errors.Join(append([]error{err}, loaded.invalid...)...)
+}
+
+// applyConfig makes a loaded configuration the one in use
+func applyConfig(loaded *loadedConfig) {
+	active.Store(loaded)
+	system.ApplySettings(loaded.settings)
+}
+
+// Reload reads the configuration sources again and applies the new configuration
+// A configuration that fails validation is rejected and the current configuration stays in place.
+// The port, TLS options, collection interval and metrics endpoint only change on restart.
+func Reload() error {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	loaded, err := loadConfig()
+	if err = strictError(loaded, err); err != nil {
+		log.Printf("Configuration reload rejected, keeping the current configuration: %v", err)
+		return err
+	}
+
+	keepStartupValues(active.Load(), loaded)
+	applyConfig(loaded)
+	log.Println("Configuration reloaded")
+	return nil
+}
+
+// keepStartupValues copies the values that only take effect on start from the configuration in use
+func keepStartupValues(current, loaded *loadedConfig) {
+	if loaded.values.port != current.values.port {
+		log.Printf("PORT changed from %s to %s, restart required", current.values.port, loaded.values.port)
+		loaded.values.port = current.values.port
+		loaded.sources["PORT"] = current.sources["PORT"]
+	}
+	if loaded.tls != current.tls {
+		log.Println("TLS options changed, restart required")
+		loaded.tls = current.tls
+	}
+	if loaded.values.collectInterval != current.values.collectInterval {
+		log.Printf("COLLECT_INTERVAL changed from %s to %s, restart required", current.values.collectInterval, loaded.values.collectInterval)
+		loaded.values.collectInterval = current.values.collectInterval
+		loaded.sources["COLLECT_INTERVAL"] = current.sources["COLLECT_INTERVAL"]
+	}
+	if loaded.values.disableMetrics != current.values.disableMetrics {
+		log.Println("DISABLE_METRICS changed, restart required")
+		loaded.values.disableMetrics = current.values.disableMetrics
+		loaded.sources["DISABLE_METRICS"] = current.sources["DISABLE_METRICS"]
+	}
+}
+
+// WatchConfig reloads the configuration on SIGHUP and when the config file, .env file
+// or tokens file changes, until the context is cancelled
+func WatchConfig(ctx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hangup)
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+
+		seen := watchedModTimes()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				log.Println("Received SIGHUP, reloading configuration")
+			case <-ticker.C:
+				if maps.Equal(watchedModTimes(), seen) {
+					continue
+				}
+				log.Println("Configuration file changed, reloading configuration")
+			}
+
+			_ = Reload() // Errors are logged and the current configuration is kept
+			seen = watchedModTimes()
+		}
+	}()
+}
+
+// watchedModTimes returns the modification times of the configuration files in use
+// Files that do not exist have a zero time, so creating them triggers a reload.
+func watchedModTimes() map[string]time.Time {
+	loaded := active.Load()
+	modTimes := make(map[string]time.Time)
+	for _, path := range []string{loaded.envFile, loaded.values.configPath, loaded.values.tokensFile} {
+		if path == "" {
+			continue
+		}
+		var modTime time.Time
+		if stat, err := os.Stat(path); err == nil {
+			modTime = stat.ModTime()
+		}
+		modTimes[path] = modTime
+	}
+	return modTimes
+}
@@ -0,0 +1,242 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Sources a configuration value can come from, from highest to lowest precedence
+const (
+	SourceFlag       = "flag"
+	SourceEnv        = "environment"
+	SourceEnvFile    = ".env file"
+	SourceConfigFile = "config file"
+	SourceDefault    = "default"
+)
+
+// option is a configuration value that can be set by a flag, an environment variable,
+// the .env file or the config file
+type option struct {
+	flag     string                // Command line flag name
+	env      string                // Environment variable name, also used as the key for the config file
+	validate func(string) error    // Checks a value before it is applied, may be nil
+	apply    func(*config, string) // Stores the value
+	format   func(*config) string  // Returns the value for display
+	secret   bool                  // Whether the value is masked for display
+}
+
+// ValueSource is a configuration value and the source it was resolved from
+type ValueSource struct {
+	Name   string // Environment variable name
+	Value  string // Value in use, secrets are masked
+	Source string // One of the Source constants
+}
+
+// options lists every configuration value that is resolved from the configuration sources
+var options = []option{
+	secretOption("token", "SECRET_TOKEN", func(c *config) *string { return &c.secretToken }),
+	stringOption("tokens-file", "TOKENS_FILE", func(c *config) *string { return &c.tokensFile }),
+	stringOption("port", "PORT", func(c *config) *string { return &c.port }),
+	stringOption("ignore-mounts", "IGNORE_MOUNTPOINTS", func(c *config) *string { return &c.ignoreMountpoints }),
+	stringOption("override-mounts", "OVERRIDE_IGNORED_MOUNTPOINTS", func(c *config) *string { return &c.overrideIgnoreMountpoints }),
+	stringOption("ignore-interfaces", "IGNORE_INTERFACES", func(c *config) *string { return &c.ignoreInterfaces }),
+	stringOption("include-interfaces", "INCLUDE_INTERFACES", func(c *config) *string { return &c.includeInterfaces }),
+	stringOption("whitelist-ip", "WHITELIST_IPS", func(c *config) *string { return &c.whitelistedIPs }),
+	boolOption("whitelist-only", "WHITELIST_ONLY", func(c *config) *bool { return &c.whitelistOnly }),
+	stringOption("trusted-proxies", "TRUSTED_PROXIES", func(c *config) *string { return &c.trustedProxies }),
+	stringOption("trusted-proxy-header", "TRUSTED_PROXY_HEADER", func(c *config) *string { return &c.trustedProxyHeader }),
+	stringOption("tls-cert", "TLS_CERT", func(c *config) *string { return &c.tlsCert }),
+	stringOption("tls-key", "TLS_KEY", func(c *config) *string { return &c.tlsKey }),
+	boolOption("tls-self-signed", "TLS_SELF_SIGNED", func(c *config) *bool { return &c.tlsSelfSigned }),
+	stringOption("tls-client-ca", "TLS_CLIENT_CA", func(c *config) *string { return &c.tlsClientCA }),
+	stringOption("tls-client-auth", "TLS_CLIENT_AUTH", func(c *config) *string { return &c.tlsClientAuth }),
+	intOption("thermal-zone", "THERMAL_ZONE", func(c *config) *int { return &c.thermalZone }),
+	intervalOption("collect-interval", "COLLECT_INTERVAL", func(c *config) *time.Duration { return &c.collectInterval }),
+	intervalOption("zfs-interval", "ZFS_INTERVAL", func(c *config) *time.Duration { return &c.zfsInterval }),
+	boolOption("cgroup-aware", "CGROUP_AWARE", func(c *config) *bool { return &c.cgroupAware }),
+	boolOption("disable-cpu", "DISABLE_CPU_LOAD", func(c *config) *bool { return &c.featureToggles.DisableCPULoad }),
+	boolOption("disable-cpu-usage", "DISABLE_CPU_USAGE", func(c *config) *bool { return &c.featureToggles.DisableCPUUsage }),
+	boolOption("disable-temp", "DISABLE_TEMPERATURE", func(c *config) *bool { return &c.featureToggles.DisableTemperature }),
+	boolOption("disable-sensors", "DISABLE_SENSORS", func(c *config) *bool { return &c.featureToggles.DisableSensors }),
+	boolOption("disable-memory", "DISABLE_MEMORY", func(c *config) *bool { return &c.featureToggles.DisableMemory }),
+	boolOption("disable-swap", "DISABLE_SWAP", func(c *config) *bool { return &c.featureToggles.DisableSwap }),
+	boolOption("disable-disk", "DISABLE_DISK", func(c *config) *bool { return &c.featureToggles.DisableDisk }),
+	boolOption("disable-disk-io", "DISABLE_DISK_IO", func(c *config) *bool { return &c.featureToggles.DisableDiskIO }),
+	boolOption("disable-host", "DISABLE_HOST", func(c *config) *bool { return &c.featureToggles.DisableHost }),
+	boolOption("disable-network", "DISABLE_NETWORK", func(c *config) *bool { return &c.featureToggles.DisableNetwork }),
+	boolOption("disable-pressure", "DISABLE_PRESSURE", func(c *config) *bool { return &c.featureToggles.DisablePressure }),
+	boolOption("disable-processes", "DISABLE_PROCESSES", func(c *config) *bool { return &c.featureToggles.DisableProcesses }),
+	intOption("top-processes", "TOP_PROCESSES", func(c *config) *int { return &c.topProcesses }),
+	boolOption("redact-cmdline", "REDACT_COMMAND_LINES", func(c *config) *bool { return &c.redactCommandLines }),
+	boolOption("disable-containers", "DISABLE_CONTAINERS", func(c *config) *bool { return &c.featureToggles.DisableContainers }),
+	stringOption("docker-socket", "DOCKER_SOCKET", func(c *config) *string { return &c.containerSocket }),
+	stringOption("include-container-labels", "CONTAINER_INCLUDE_LABELS", func(c *config) *string { return &c.containerIncludeLabels }),
+	stringOption("exclude-container-labels", "CONTAINER_EXCLUDE_LABELS", func(c *config) *string { return &c.containerExcludeLabels }),
+	boolOption("disable-metrics", "DISABLE_METRICS", func(c *config) *bool { return &c.disableMetrics }),
+	intervalOption("stream-min-interval", "STREAM_MIN_INTERVAL", func(c *config) *time.Duration { return &c.streamMinInterval }),
+	intervalOption("stream-max-interval", "STREAM_MAX_INTERVAL", func(c *config) *time.Duration { return &c.streamMaxInterval }),
+	intOption("stream-max-clients", "STREAM_MAX_CLIENTS", func(c *config) *int { return &c.streamMaxClients }),
+	intOption("history-max-memory", "HISTORY_MAX_MEMORY_MB", func(c *config) *int { return &c.historyMaxMemoryMB }),
+	stringOption("extension-templates", "EXTENSION_TEMPLATES_DIR", func(c *config) *string { return &c.extensionTemplatesDir }),
+	stringOption("host-root", "HOST_ROOT", func(c *config) *string { return &c.hostRoot }),
+	stringOption("host-proc", "HOST_PROC", func(c *config) *string { return &c.hostProc }),
+	stringOption("host-sys", "HOST_SYS", func(c *config) *string { return &c.hostSys }),
+	stringOption("host-etc", "HOST_ETC", func(c *config) *string { return &c.hostEtc }),
+}
+
+// stringOption returns an option that stores the value as is
+func stringOption(flagName, envName string, field func(*config) *string) option {
+	return option{
+		flag:   flagName,
+		env:    envName,
+		apply:  func(c *config, value string) { *field(c) = value },
+		format: func(c *config) string { return *field(c) },
+	}
+}
+
+// secretOption returns an option that stores the value as is and is masked for display
+func secretOption(flagName, envName string, field func(*config) *string) option {
+	opt := stringOption(flagName, envName, field)
+	opt.secret = true
+	return opt
+}
+
+// boolOption returns an option that is enabled by the value "true"
+func boolOption(flagName, envName string, field func(*config) *bool) option {
+	return option{
+		flag:   flagName,
+		env:    envName,
+		apply:  func(c *config, value string) { *field(c) = value == "true" },
+		format: func(c *config) string { return strconv.FormatBool(*field(c)) },
+	}
+}
+
+// intOption returns an option that stores the value as an integer
+func intOption(flagName, envName string, field func(*config) *int) option {
+	return option{
+		flag: flagName,
+		env:  envName,
+		validate: func(value string) error {
+			_, err := strconv.Atoi(value)
+			return err
+		},
+		apply:  func(c *config, value string) { *field(c), _ = strconv.Atoi(value) },
+		format: func(c *config) string { return strconv.Itoa(*field(c)) },
+	}
+}
+
+// intervalOption returns an option that stores the value as a duration, see parseInterval
+func intervalOption(flagName, envName string, field func(*config) *time.Duration) option {
+	return option{
+		flag: flagName,
+		env:  envName,
+		validate: func(value string) error {
+			_, err := parseInterval(value)
+			return err
+		},
+		apply:  func(c *config, value string) { *field(c), _ = parseInterval(value) },
+		format: func(c *config) string { return field(c).String() },
+	}
+}
+
+// GetValueSources returns every configuration value in use and the source it was resolved from
+func GetValueSources() []ValueSource {
+	loaded := active.Load()
+	valueSources := make([]ValueSource, 0, len(options)+1)
+	valueSources = append(valueSources, ValueSource{
+		Name:   "CONFIG_FILE",
+		Value:  loaded.values.configPath,
+		Source: loaded.sources["CONFIG_FILE"],
+	})
+	for _, opt := range options {
+		value := opt.format(&loaded.values)
+		if opt.secret && value != "" {
+			value = "***"
+		}
+		valueSources = append(valueSources, ValueSource{Name: opt.env, Value: value, Source: loaded.sources[opt.env]})
+	}
+	return valueSources
+}
+
+// resolveSources resolves every option from multiple sources with precedence:
+// 1. Command line flags (highest priority)
+// 2. Environment variables
+// 3. .env file
+// 4. Config file (lowest priority)
+// Returns the resolved values, the source of each value keyed by environment variable name,
+// and the invalid values, which are left at their default.
+func resolveSources(dotEnv, fileValues map[string]string) (config, map[string]string, []error) {
+	values := flagValues
+	sources := make(map[string]string, len(options))
+	var problems []error
+
+	for _, opt := range options {
+		if flagWasSet(opt.flag) {
+			sources[opt.env] = SourceFlag
+			continue
+		}
+
+		value, source, found := lookupValue(opt.env, dotEnv, fileValues)
+		if !found {
+			sources[opt.env] = SourceDefault
+			continue
+		}
+
+		if opt.validate != nil {
+			if err := opt.validate(value); err != nil {
+				problems = append(problems, fmt.Errorf("invalid %s value from %s: %q", opt.env, source, value))
+				sources[opt.env] = SourceDefault
+				continue
+			}
+		}
+
+		opt.apply(&values, value)
+		sources[opt.env] = source
+	}
+
+	return values, sources, problems
+}
+
+// lookupValue returns a configuration value and its source from the environment, the .env file
+// or the config file. Empty values are treated as unset, except in the config file.
+func lookupValue(name string, dotEnv, fileValues map[string]string) (string, string, bool) {
+	if value := os.Getenv(name); value != "" {
+		return value, SourceEnv, true
+	}
+	if value := dotEnv[name]; value != "" {
+		return value, SourceEnvFile, true
+	}
+	if value, exists := fileValues[name]; exists {
+		return value, SourceConfigFile, true
+	}
+	return "", "", false
+}
+
+// flagWasSet reports whether a flag was set on the command line
+func flagWasSet(name string) bool {
+	found := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	return found
+}
@@ -0,0 +1,163 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"errors"
+	"flag"
+	"slices"
+	"testing"
+	"time"
+)
+
+// setTestSources replaces the command line flags and clears the environment for the duration of a test
+// Only the port and collect interval flags are defined, with the given ones set.
+func setTestSources(t *testing.T, set map[string]string) {
+	t.Helper()
+	for _, opt := range options {
+		t.Setenv(opt.env, "") // Empty values are treated as unset
+	}
+	previousFlags, previousValues := flag.CommandLine, flagValues
+	t.Cleanup(func() {
+		flag.CommandLine, flagValues = previousFlags, previousValues
+	})
+
+	flagValues = config{}
+	flag.CommandLine = flag.NewFlagSet("test", flag.ContinueOnError)
+	flag.StringVar(&flagValues.port, "port", "9012", "")
+	flag.DurationVar(&flagValues.collectInterval, "collect-interval", 10*time.Second, "")
+	for name, value := range set {
+		if err := flag.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResolveSourcesPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       string
+		env        string
+		dotEnv     string
+		file       *string
+		want       string
+		wantSource string
+	}{
+		{name: "flag over every other source", flag: "1001", env: "1002", dotEnv: "1003", file: ptr("1004"), want: "1001", wantSource: SourceFlag},
+		{name: "environment over files", env: "1002", dotEnv: "1003", file: ptr("1004"), want: "1002", wantSource: SourceEnv},
+		{name: ".env file over config file", dotEnv: "1003", file: ptr("1004"), want: "1003", wantSource: SourceEnvFile},
+		{name: "config file", file: ptr("1004"), want: "1004", wantSource: SourceConfigFile},
+		{name: "empty config file value is kept", file: ptr(""), want: "", wantSource: SourceConfigFile},
+		{name: "default", want: "9012", wantSource: SourceDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := map[string]string{}
+			if tt.flag != "" {
+				flags["port"] = tt.flag
+			}
+			setTestSources(t, flags)
+			t.Setenv("PORT", tt.env)
+			fileValues := map[string]string{}
+			if tt.file != nil {
+				fileValues["PORT"] = *tt.file
+			}
+
+			values, sources, problems := resolveSources(map[string]string{"PORT": tt.dotEnv}, fileValues)
+			if len(problems) > 0 {
+				t.Fatalf("resolveSources() problems = %v", problems)
+			}
+			if values.port != tt.want || sources["PORT"] != tt.wantSource {
+				t.Errorf("PORT = %q from %s, want %q from %s", values.port, sources["PORT"], tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestResolveSourcesInvalidValues(t *testing.T) {
+	setTestSources(t, nil)
+	t.Setenv("COLLECT_INTERVAL", "often")
+	dotEnv := map[string]string{"TOP_PROCESSES": "many", "STREAM_MIN_INTERVAL": "-5s"}
+	fileValues := map[string]string{"HISTORY_MAX_MEMORY_MB": "16MB", "ZFS_INTERVAL": "90"}
+
+	values, sources, problems := resolveSources(dotEnv, fileValues)
+
+	want := []string{
+		`invalid COLLECT_INTERVAL value from environment: "often"`,
+		`invalid TOP_PROCESSES value from .env file: "many"`,
+		`invalid STREAM_MIN_INTERVAL value from .env file: "-5s"`,
+		`invalid HISTORY_MAX_MEMORY_MB value from config file: "16MB"`,
+	}
+	got := make([]string, len(problems))
+	for i, problem := range problems {
+		got[i] = problem.Error()
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("problems = %q, want %q", got, want)
+	}
+
+	// Invalid values are left at their default, valid ones are still applied
+	for _, name := range []string{"COLLECT_INTERVAL", "TOP_PROCESSES", "STREAM_MIN_INTERVAL", "HISTORY_MAX_MEMORY_MB"} {
+		if sources[name] != SourceDefault {
+			t.Errorf("%s from %s, want %s", name, sources[name], SourceDefault)
+		}
+	}
+	if values.collectInterval != 10*time.Second {
+		t.Errorf("COLLECT_INTERVAL = %s, want the default 10s", values.collectInterval)
+	}
+	if values.zfsInterval != 90*time.Second || sources["ZFS_INTERVAL"] != SourceConfigFile {
+		t.Errorf("ZFS_INTERVAL = %s from %s, want 1m30s from %s", values.zfsInterval, sources["ZFS_INTERVAL"], SourceConfigFile)
+	}
+}
+
+func TestStrictError(t *testing.T) {
+	invalid := errors.New(`invalid PORT value from environment: "http"`)
+	other := errors.New("no API token configured")
+
+	tests := []struct {
+		name    string
+		loaded  *loadedConfig
+		err     error
+		wantErr []error
+	}{
+		{name: "valid", loaded: &loadedConfig{}},
+		{name: "invalid values only", loaded: &loadedConfig{invalid: []error{invalid}}, wantErr: []error{invalid}},
+		{name: "other problems only", loaded: &loadedConfig{}, err: other, wantErr: []error{other}},
+		{name: "both", loaded: &loadedConfig{invalid: []error{invalid}}, err: other, wantErr: []error{other, invalid}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := strictError(tt.loaded, tt.err)
+			if (err != nil) != (len(tt.wantErr) > 0) {
+				t.Fatalf("strictError() = %v, want %v", err, tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("strictError() = %v, want it to include %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+// ptr returns a pointer to a copy of a value
+func ptr[T any](value T) *T {
+	return &value
+}
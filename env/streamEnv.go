@@ -0,0 +1,61 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"fmt"
+	"time"
+)
+
+// StreamOptions holds the limits of the live metrics stream
+type StreamOptions struct {
+	MinInterval time.Duration // Shortest interval a client can request
+	MaxInterval time.Duration // Longest interval a client can request
+	MaxClients  int           // Maximum number of concurrent streams, 0 disables streaming
+}
+
+// Interval returns the requested interval limited to the configured bounds
+// A requested interval of 0 selects the collection interval.
+func (o StreamOptions) Interval(requested time.Duration) time.Duration {
+	if requested <= 0 {
+		requested = GetCollectInterval()
+	}
+	return min(max(requested, o.MinInterval), o.MaxInterval)
+}
+
+// GetStreamOptions returns the limits of the live metrics stream
+func GetStreamOptions() StreamOptions {
+	return active.Load().stream
+}
+
+// buildStreamOptions returns the stream limits
+func buildStreamOptions(c *config) (StreamOptions, error) {
+	options := StreamOptions{
+		MinInterval: c.streamMinInterval,
+		MaxInterval: c.streamMaxInterval,
+		MaxClients:  c.streamMaxClients,
+	}
+	if options.MinInterval <= 0 {
+		return StreamOptions{}, fmt.Errorf("STREAM_MIN_INTERVAL must be greater than 0")
+	}
+	if options.MaxInterval < options.MinInterval {
+		return StreamOptions{}, fmt.Errorf("STREAM_MAX_INTERVAL (%s) must not be shorter than STREAM_MIN_INTERVAL (%s)", options.MaxInterval, options.MinInterval)
+	}
+	if options.MaxClients < 0 {
+		return StreamOptions{}, fmt.Errorf("STREAM_MAX_CLIENTS must not be negative")
+	}
+	return options, nil
+}
@@ -0,0 +1,88 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"glance-agent/certs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// defaultStateDir is where generated files are stored on Linux when systemd does not provide a state directory
+const defaultStateDir = "/var/lib/glance-agent"
+
+// GetTLSOptions returns the TLS configuration for the HTTP listener
+func GetTLSOptions() certs.Options {
+	return active.Load().tls
+}
+
+// buildTLSOptions returns the TLS options
+// A self-signed certificate is stored in the state directory unless TLS_CERT and TLS_KEY are set.
+func buildTLSOptions(c *config) (certs.Options, error) {
+	clientAuth := c.tlsClientAuth
+	if clientAuth == "" {
+		clientAuth = certs.ClientAuthNone
+		if c.tlsClientCA != "" {
+			clientAuth = certs.ClientAuthRequire
+		}
+	}
+
+	certFile, keyFile := c.tlsCert, c.tlsKey
+	if c.tlsSelfSigned && (certFile == "" || keyFile == "") {
+		stateDir, err := getStateDir()
+		if err != nil {
+			return certs.Options{}, err
+		}
+		if certFile == "" {
+			certFile = filepath.Join(stateDir, "tls.crt")
+		}
+		if keyFile == "" {
+			keyFile = filepath.Join(stateDir, "tls.key")
+		}
+	}
+
+	options := certs.Options{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		SelfSigned:   c.tlsSelfSigned,
+		ClientCAFile: c.tlsClientCA,
+		ClientAuth:   clientAuth,
+	}
+	if err := options.Validate(); err != nil {
+		return certs.Options{}, err
+	}
+	return options, nil
+}
+
+// getStateDir returns the directory generated files are stored in
+// This is the StateDirectory= of the systemd unit, /var/lib/glance-agent on Linux otherwise,
+// and the directory of the binary on Windows.
+func getStateDir() (string, error) {
+	if stateDirs := os.Getenv("STATE_DIRECTORY"); stateDirs != "" {
+		stateDir, _, _ := strings.Cut(stateDirs, ":") // systemd lists one directory per StateDirectory= entry
+		return stateDir, nil
+	}
+	if runtime.GOOS != "windows" {
+		return defaultStateDir, nil
+	}
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(execPath), nil
+}
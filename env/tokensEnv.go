@@ -0,0 +1,192 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Token scopes
+const (
+	ScopeSysinfoRead = "sysinfo:read" // Read system information from /api/sysinfo
+	ScopeMetricsRead = "metrics:read" // Scrape the Prometheus /metrics endpoint
+	ScopeAdmin       = "admin"        // Grants every scope
+)
+
+// validScopes lists every scope that can be assigned to a token
+var validScopes = []string{ScopeSysinfoRead, ScopeMetricsRead, ScopeAdmin}
+
+// legacyTokenName is the name given to the token configured with SECRET_TOKEN
+const legacyTokenName = "default"
+
+// Token is a named API token
+type Token struct {
+	Name       string   // Name used in logs to identify the token
+	Hash       []byte   // SHA-256 hash of the token
+	Scopes     []string // Scopes granted to the token
+	AllowedIPs []string // IPs or CIDR ranges the token may be used from, empty allows any
+}
+
+// HasScope reports whether the token grants the given scope
+func (t Token) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, ScopeAdmin) || slices.Contains(t.Scopes, scope)
+}
+
+// HashToken returns the SHA-256 hash of a token
+func HashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// buildTokens returns the API tokens from SECRET_TOKEN, the config file and the tokens file
+func buildTokens(c *config, fileTokens []Token) ([]Token, error) {
+	configured := []Token{}
+
+	// SECRET_TOKEN is kept as a single token with full access
+	if c.secretToken != "" {
+		configured = append(configured, Token{
+			Name:   legacyTokenName,
+			Hash:   HashToken(c.secretToken),
+			Scopes: []string{ScopeAdmin},
+		})
+	}
+
+	// Tokens defined in the config file
+	for _, token := range fileTokens {
+		if slices.ContainsFunc(configured, func(t Token) bool { return t.Name == token.Name }) {
+			return nil, fmt.Errorf("config file: duplicate token name %q", token.Name)
+		}
+		configured = append(configured, token)
+	}
+
+	if c.tokensFile != "" {
+		listedTokens, err := loadTokensFile(c.tokensFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, token := range listedTokens {
+			if slices.ContainsFunc(configured, func(t Token) bool { return t.Name == token.Name }) {
+				return nil, fmt.Errorf("%s: duplicate token name %q", c.tokensFile, token.Name)
+			}
+			configured = append(configured, token)
+		}
+		log.Printf("Loaded %d tokens from %s", len(listedTokens), c.tokensFile)
+	}
+
+	// No listener is opened by the once subcommand, so it does not need a token
+	if len(configured) == 0 && command != CommandOnce {
+		return nil, fmt.Errorf("SECRET_TOKEN, TOKENS_FILE or tokens in the config file are required. Set via environment variable, .env file, config file, or -token/-tokens-file flag")
+	}
+
+	return configured, nil
+}
+
+// loadTokensFile reads API tokens from a file
+// Each line holds a name, the SHA-256 hash of the token in hex, comma-separated scopes
+// and optionally comma-separated IPs or CIDR ranges, separated by whitespace:
+//
+//	# name      sha256                        scopes        ips
+//	dashboard   <sha256-of-dashboard-token>   sysinfo:read
+//	prometheus  <sha256-of-prometheus-token>  metrics:read  10.0.0.5
+func loadTokensFile(path string) ([]Token, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tokens file: %w", err)
+	}
+	defer file.Close()
+
+	var fileTokens []Token
+	names := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue // Skip empty lines and comments
+		}
+
+		token, err := parseTokenLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		if names[token.Name] {
+			return nil, fmt.Errorf("%s:%d: duplicate token name %q", path, lineNumber, token.Name)
+		}
+		names[token.Name] = true
+		fileTokens = append(fileTokens, token)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read tokens file: %w", err)
+	}
+
+	return fileTokens, nil
+}
+
+// parseTokenLine parses a single line of the tokens file
+func parseTokenLine(line string) (Token, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || len(fields) > 4 {
+		return Token{}, fmt.Errorf("expected \"name sha256 scopes [ips]\", got %d fields", len(fields))
+	}
+
+	var allowedIPs []string
+	if len(fields) == 4 {
+		allowedIPs = splitList(fields[3])
+	}
+	return newToken(fields[0], fields[1], splitList(fields[2]), allowedIPs)
+}
+
+// newToken validates the parts of a token and returns it
+// The hash is a hex encoded SHA-256 digest, optionally prefixed with "sha256:".
+func newToken(name, hash string, scopes, allowedIPs []string) (Token, error) {
+	if name == "" {
+		return Token{}, fmt.Errorf("token name must not be empty")
+	}
+	token := Token{Name: name}
+
+	digest, err := hex.DecodeString(strings.TrimPrefix(hash, "sha256:"))
+	if err != nil || len(digest) != sha256.Size {
+		return Token{}, fmt.Errorf("token %q: hash must be a hex encoded SHA-256 digest", name)
+	}
+	token.Hash = digest
+
+	if len(scopes) == 0 {
+		return Token{}, fmt.Errorf("token %q: at least one scope is required", name)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(validScopes, scope) {
+			return Token{}, fmt.Errorf("token %q: unknown scope %q (valid scopes: %s)", name, scope, strings.Join(validScopes, ", "))
+		}
+		token.Scopes = append(token.Scopes, scope)
+	}
+
+	for _, entry := range allowedIPs {
+		if !isIPOrCIDR(entry) {
+			return Token{}, fmt.Errorf("token %q: invalid IP or CIDR %q", name, entry)
+		}
+		token.AllowedIPs = append(token.AllowedIPs, entry)
+	}
+
+	return token, nil
+}
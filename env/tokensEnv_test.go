@@ -0,0 +1,162 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testTokenHash is the SHA-256 hash of "dashboard-token"
+const testTokenHash = "66e7ac6f0a86850313c536f2b5b8fbaab05647f13ce254c24982e254bd7293c4"
+
+func TestParseTokenLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    Token
+		wantErr string
+	}{
+		{
+			name: "scopes only",
+			line: "dashboard " + testTokenHash + " sysinfo:read",
+			want: Token{Name: "dashboard", Hash: HashToken("dashboard-token"), Scopes: []string{ScopeSysinfoRead}},
+		},
+		{
+			name: "prefixed hash, several scopes and IPs",
+			line: "prometheus\tsha256:" + testTokenHash + "  sysinfo:read,metrics:read  10.0.0.5,192.168.1.0/24,fd00::/8",
+			want: Token{
+				Name:       "prometheus",
+				Hash:       HashToken("dashboard-token"),
+				Scopes:     []string{ScopeSysinfoRead, ScopeMetricsRead},
+				AllowedIPs: []string{"10.0.0.5", "192.168.1.0/24", "fd00::/8"},
+			},
+		},
+		{name: "too few fields", line: "dashboard " + testTokenHash, wantErr: `expected "name sha256 scopes [ips]", got 2 fields`},
+		{name: "too many fields", line: "dashboard " + testTokenHash + " admin 10.0.0.5 extra", wantErr: "got 5 fields"},
+		{name: "hash too short", line: "dashboard " + testTokenHash[:62] + " admin", wantErr: `token "dashboard": hash must be a hex encoded SHA-256 digest`},
+		{name: "hash too long", line: "dashboard " + testTokenHash + "00 admin", wantErr: "hash must be a hex encoded SHA-256 digest"},
+		{name: "hash not hex", line: "dashboard " + strings.Repeat("z", 64) + " admin", wantErr: "hash must be a hex encoded SHA-256 digest"},
+		{name: "placeholder hash", line: "dashboard <sha256-of-dashboard-token> admin", wantErr: "hash must be a hex encoded SHA-256 digest"},
+		{name: "unknown scope", line: "dashboard " + testTokenHash + " sysinfo:write", wantErr: `token "dashboard": unknown scope "sysinfo:write" (valid scopes: sysinfo:read, metrics:read, admin)`},
+		{name: "empty scope", line: "dashboard " + testTokenHash + " admin,", wantErr: `unknown scope ""`},
+		{name: "malformed IP", line: "dashboard " + testTokenHash + " admin 10.0.0.256", wantErr: `token "dashboard": invalid IP or CIDR "10.0.0.256"`},
+		{name: "malformed CIDR", line: "dashboard " + testTokenHash + " admin 10.0.0.0/33", wantErr: `invalid IP or CIDR "10.0.0.0/33"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTokenLine(tt.line)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseTokenLine() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTokenLine() error = %v", err)
+			}
+			if !equalTokens(got, tt.want) {
+				t.Errorf("parseTokenLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadTokensFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	content := "# name sha256 scopes ips\n\ndashboard " + testTokenHash + " sysinfo:read\nprometheus " + testTokenHash + " metrics:reed\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := loadTokensFile(path)
+	if err == nil || !strings.HasPrefix(err.Error(), path+":4: ") || !strings.Contains(err.Error(), `unknown scope "metrics:reed"`) {
+		t.Errorf("loadTokensFile() error = %v, want the unknown scope on line 4", err)
+	}
+
+	content = "dashboard " + testTokenHash + " sysinfo:read\ndashboard " + testTokenHash + " admin\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTokensFile(path); err == nil || err.Error() != path+`:2: duplicate token name "dashboard"` {
+		t.Errorf("loadTokensFile() error = %v, want a duplicate name on line 2", err)
+	}
+}
+
+func TestBuildTokens(t *testing.T) {
+	fileToken := Token{Name: "dashboard", Hash: HashToken("dashboard-token"), Scopes: []string{ScopeSysinfoRead}}
+
+	tests := []struct {
+		name       string
+		values     config
+		fileTokens []Token
+		want       []Token
+		wantErr    string
+	}{
+		{
+			name:   "SECRET_TOKEN is the default admin token",
+			values: config{secretToken: "secret"},
+			want:   []Token{{Name: "default", Hash: HashToken("secret"), Scopes: []string{ScopeAdmin}}},
+		},
+		{
+			name:       "SECRET_TOKEN alongside config file tokens",
+			values:     config{secretToken: "secret"},
+			fileTokens: []Token{fileToken},
+			want:       []Token{{Name: "default", Hash: HashToken("secret"), Scopes: []string{ScopeAdmin}}, fileToken},
+		},
+		{
+			name:       "config file token named default",
+			values:     config{secretToken: "secret"},
+			fileTokens: []Token{{Name: "default", Hash: HashToken("other"), Scopes: []string{ScopeSysinfoRead}}},
+			wantErr:    `config file: duplicate token name "default"`,
+		},
+		{
+			name:    "no token",
+			wantErr: "SECRET_TOKEN, TOKENS_FILE or tokens in the config file are required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildTokens(&tt.values, tt.fileTokens)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("buildTokens() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildTokens() error = %v", err)
+			}
+			if !slices.EqualFunc(got, tt.want, equalTokens) {
+				t.Errorf("buildTokens() = %+v, want %+v", got, tt.want)
+			}
+			if !got[0].HasScope(ScopeMetricsRead) {
+				t.Errorf("%s token does not grant %s", got[0].Name, ScopeMetricsRead)
+			}
+		})
+	}
+}
+
+// equalTokens reports whether two tokens are the same
+func equalTokens(a, b Token) bool {
+	return a.Name == b.Name && bytes.Equal(a.Hash, b.Hash) && slices.Equal(a.Scopes, b.Scopes) && slices.Equal(a.AllowedIPs, b.AllowedIPs)
+}
@@ -0,0 +1,67 @@
+package env
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Headers a trusted proxy can pass the client address in
+const (
+	ProxyHeaderXForwardedFor = "X-Forwarded-For"
+	ProxyHeaderForwarded     = "Forwarded"
+	ProxyHeaderXRealIP       = "X-Real-IP"
+)
+
+// proxyHeaders lists the supported proxy headers
+var proxyHeaders = []string{ProxyHeaderXForwardedFor, ProxyHeaderForwarded, ProxyHeaderXRealIP}
+
+// buildTrustedProxies returns the IPs and CIDR ranges of reverse proxies whose forwarded headers are trusted
+func buildTrustedProxies(c *config) []string {
+	trusted := []string{}
+	if c.trustedProxies == "" {
+		return trusted
+	}
+
+	for _, entry := range splitList(c.trustedProxies) {
+		if entry == "" {
+			continue
+		}
+		if !isIPOrCIDR(entry) {
+			log.Printf("Ignoring invalid trusted proxy: %s", entry)
+			continue
+		}
+		trusted = append(trusted, entry)
+	}
+	log.Printf("Trusted proxies: %v", trusted)
+	return trusted
+}
+
+// buildProxyHeader returns the header trusted proxies pass the client address in
+// Only this header is read, proxies commonly pass the other headers on from the client untouched.
+func buildProxyHeader(c *config) (string, error) {
+	if c.trustedProxyHeader == "" {
+		return ProxyHeaderXForwardedFor, nil
+	}
+	for _, header := range proxyHeaders {
+		if strings.EqualFold(c.trustedProxyHeader, header) {
+			return header, nil
+		}
+	}
+	return ProxyHeaderXForwardedFor, fmt.Errorf("TRUSTED_PROXY_HEADER must be one of %s, got %q", strings.Join(proxyHeaders, ", "), c.trustedProxyHeader)
+}
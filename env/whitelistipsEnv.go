@@ -16,35 +16,35 @@ package env
 // along with this program. If not, see <https://www.gnu.org/licenses/>.
 
 import (
+	"errors"
 	"log"
-	"strings"
+	"net"
 )
 
-var WhitelistIParr []string
-var WhitelistOnlyBool bool
-
-// configureWhitelistIPs sets up whitelistIP ignore lists
-func configureWhitelistIPs() {
+// buildWhitelist returns the whitelisted IPs and CIDR ranges
+// Whitelist only mode without a whitelist would deny every request, so it is rejected.
+func buildWhitelist(c *config) ([]string, error) {
+	whitelist := []string{}
 	// Add extra whitelistIPs from configuration
-	if whitelistedIPs != "" {
-		WhitelistIParr = strings.Split(whitelistedIPs, ",")
-		for i, mp := range WhitelistIParr {
-			WhitelistIParr[i] = strings.TrimSpace(mp)
-		}
-		log.Printf("Added whitelisted IPs: %v", WhitelistIParr)
-	} else {
-		WhitelistIParr = []string{}
+	if c.whitelistedIPs != "" {
+		whitelist = splitList(c.whitelistedIPs)
+		log.Printf("Added whitelisted IPs: %v", whitelist)
 	}
 
-	WhitelistOnlyBool = whitelistOnly
-
-	if (WhitelistOnlyBool && WhitelistIParr == nil) || (WhitelistOnlyBool && len(WhitelistIParr) == 0) {
-		log.Fatalln("Whitelist only mode enabled and no whitelist defined. Exiting...")
-	} else if WhitelistOnlyBool {
+	if c.whitelistOnly && len(whitelist) == 0 {
+		return nil, errors.New("whitelist only mode enabled and no whitelist defined")
+	} else if c.whitelistOnly {
 		log.Println("Whitelist only mode enabled.")
 	} else {
 		log.Println("Whitelist only mode disabled.")
 	}
+	return whitelist, nil
 }
 
-// Author: Omar Alawadhi
+// isIPOrCIDR reports whether an entry is a single IP address or a CIDR range
+func isIPOrCIDR(entry string) bool {
+	if _, _, err := net.ParseCIDR(entry); err == nil {
+		return true
+	}
+	return net.ParseIP(entry) != nil
+}
@@ -0,0 +1,162 @@
+package extension
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"glance-agent/system"
+	"html/template"
+	"io"
+	"path/filepath"
+	"time"
+)
+
+// ContentType is the content type of the rendered HTML fragments
+const ContentType = "text/html; charset=utf-8"
+
+// DefaultTemplate is rendered when no template name is requested
+const DefaultTemplate = "system"
+
+// noticePercent is the usage percentage from which bars are highlighted
+const noticePercent = 85
+
+// ErrUnknownTemplate is returned when the requested template does not exist
+var ErrUnknownTemplate = errors.New("unknown template")
+
+//go:embed templates/*.html
+var embeddedTemplates embed.FS
+
+// builtinTemplates holds the templates compiled into the binary
+var builtinTemplates = template.Must(template.New("").Funcs(funcs).ParseFS(embeddedTemplates, "templates/*.html"))
+
+// funcs are the helper functions available to templates
+var funcs = template.FuncMap{
+	"bar":      newBar,
+	"mb":       formatMB,
+	"bytes":    formatBytes,
+	"duration": formatDuration,
+	"since":    func(unix int64) time.Duration { return time.Since(time.Unix(unix, 0)) },
+}
+
+// Page is the data passed to templates, fields of the system information are available directly
+type Page struct {
+	Title string // Widget title
+	*system.SystemInfo
+}
+
+// Bar is a labelled usage bar
+type Bar struct {
+	Label   string // Name shown above the bar
+	Value   string // Value shown above the bar, e.g. "4.2 GB / 16 GB"
+	Percent int    // Fill of the bar
+	Notice  bool   // Whether the usage is high enough to highlight
+}
+
+// Render writes the named template for the system information
+// Templates in dir, if set, replace the built-in templates with the same file name and can
+// add new ones. The directory is read on every call so changes apply without a restart.
+func Render(w io.Writer, dir, name string, page Page) error {
+	templates, err := loadTemplates(dir)
+	if err != nil {
+		return err
+	}
+
+	tmpl := templates.Lookup(name + ".html")
+	if tmpl == nil {
+		return fmt.Errorf("%w %q", ErrUnknownTemplate, name)
+	}
+	return tmpl.Execute(w, page)
+}
+
+// loadTemplates returns the built-in templates overridden by the templates in dir
+func loadTemplates(dir string) (*template.Template, error) {
+	// Templates that have been executed cannot be cloned, so only clones are executed
+	templates, err := builtinTemplates.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return templates, nil
+	}
+
+	pattern := filepath.Join(dir, "*.html")
+	if matches, err := filepath.Glob(pattern); err != nil || len(matches) == 0 {
+		return templates, nil // Nothing to override
+	}
+	if _, err := templates.ParseGlob(pattern); err != nil {
+		return nil, fmt.Errorf("failed to parse templates in %s: %w", dir, err)
+	}
+	return templates, nil
+}
+
+// newBar returns a usage bar, the percentage may be any integer or float type
+func newBar(label, value string, percent any) (Bar, error) {
+	var p float64
+	switch v := percent.(type) {
+	case int:
+		p = float64(v)
+	case int64:
+		p = float64(v)
+	case uint64:
+		p = float64(v)
+	case float64:
+		p = v
+	default:
+		return Bar{}, fmt.Errorf("bar: unsupported percentage type %T", percent)
+	}
+	p = min(max(p, 0), 100)
+	return Bar{Label: label, Value: value, Percent: int(p + 0.5), Notice: p >= noticePercent}, nil
+}
+
+// formatMB formats a size in megabytes, switching to gigabytes or terabytes for large values
+func formatMB(mb int) string {
+	switch {
+	case mb >= 1024*1024:
+		return fmt.Sprintf("%.1f TB", float64(mb)/(1024*1024))
+	case mb >= 1024:
+		return fmt.Sprintf("%.1f GB", float64(mb)/1024)
+	default:
+		return fmt.Sprintf("%d MB", mb)
+	}
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. 1.5 MiB
+func formatBytes(bytes float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	unit := 0
+	for bytes >= 1024 && unit < len(units)-1 {
+		bytes /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %s", bytes, units[unit])
+}
+
+// formatDuration formats a duration in days, hours and minutes, e.g. "3d 4h"
+func formatDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
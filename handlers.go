@@ -0,0 +1,247 @@
+//go:build linux || windows
+
+package main
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"glance-agent/env"
+	"glance-agent/extension"
+	"glance-agent/metrics"
+	"glance-agent/system"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// sectionAliases maps alternative section names used in URLs to collector names
+var sectionAliases = map[string]string{
+	"disks": "disk",
+}
+
+// sysinfoHandler handles requests for system information
+// The optional fields query parameter limits the response to a comma-separated list of sections
+func sysinfoHandler(w http.ResponseWriter, r *http.Request) {
+	fields := r.URL.Query().Get("fields")
+	if fields == "" {
+		// Get the latest system information snapshot
+		info, err := system.GetSnapshot()
+		writeSystemInfo(w, info, err)
+		return
+	}
+
+	sections, err := parseSections(fields)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Get only the requested sections
+	info, err := system.GetSnapshotSections(r.Context(), sections)
+	writeSystemInfo(w, info, err)
+}
+
+// sectionHandler handles requests for a single section of the system information
+func sectionHandler(w http.ResponseWriter, r *http.Request) {
+	section, ok := resolveSection(chi.URLParam(r, "section"))
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "Unknown section")
+		return
+	}
+
+	info, err := system.GetSnapshotSections(r.Context(), []string{section})
+	writeSystemInfo(w, info, err)
+}
+
+// metricsHandler handles requests for system information in Prometheus text format
+func metricsHandler(w http.ResponseWriter, _ *http.Request) {
+	// Get the latest system information snapshot
+	info, err := system.GetSnapshot()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		// Log detailed error server-side only
+		log.Printf("System info error: %v", err)
+		return
+	}
+
+	// Return system information as Prometheus metrics
+	w.Header().Set("Content-Type", metrics.ContentType)
+	if err := metrics.WritePrometheus(w, info); err != nil {
+		log.Printf("Failed to write metrics: %v", err)
+	}
+}
+
+// historyHandler returns the history of a metric, or the names of the recorded metrics if none is given
+// Query parameters:
+//   - metric: metric name, e.g. "cpu.load1_percent" or "mountpoints[/].used_percent"
+//   - range: time range, e.g. "30m", "24h" or "7d" (default: 1h)
+func historyHandler(w http.ResponseWriter, r *http.Request) {
+	metric := r.URL.Query().Get("metric")
+	if metric == "" {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string][]string{"metrics": system.GetHistoryMetrics()}); err != nil {
+			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	timeRange := time.Hour
+	if value := r.URL.Query().Get("range"); value != "" {
+		var err error
+		if timeRange, err = parseRange(value); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid range")
+			return
+		}
+	}
+
+	series, err := system.GetHistory(metric, timeRange)
+	if errors.Is(err, system.ErrUnknownMetric) {
+		writeJSONError(w, http.StatusNotFound, "Unknown metric")
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(series); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// parseRange parses a time range such as "30m" or "24h", with "d" for days, e.g. "7d"
+func parseRange(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// extensionHandler renders system information as HTML for the Glance extension widget
+// The template is named by the URL, e.g. /api/extension/disks renders disks.html, and the
+// optional title query parameter replaces the hostname as the widget title.
+func extensionHandler(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "template")
+	if name == "" {
+		name = extension.DefaultTemplate
+	}
+
+	// Get the latest system information snapshot
+	info, err := system.GetSnapshot()
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		// Log detailed error server-side only
+		log.Printf("System info error: %v", err)
+		return
+	}
+
+	title := r.URL.Query().Get("title")
+	if title == "" {
+		title = info.Hostname
+	}
+	if title == "" {
+		title = "Glance Agent"
+	}
+
+	// Render into a buffer so a failing template does not send a partial fragment
+	var buf bytes.Buffer
+	page := extension.Page{Title: title, SystemInfo: info}
+	if err := extension.Render(&buf, env.GetExtensionTemplatesDir(), name, page); err != nil {
+		if errors.Is(err, extension.ErrUnknownTemplate) {
+			writeJSONError(w, http.StatusNotFound, "Unknown template")
+			return
+		}
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		log.Printf("Extension template error: %v", err)
+		return
+	}
+
+	w.Header().Set("Widget-Title", title)
+	w.Header().Set("Widget-Content-Type", "html")
+	w.Header().Set("Content-Type", extension.ContentType)
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("Failed to write extension response: %v", err)
+	}
+}
+
+// parseSections parses a comma-separated list of section names
+func parseSections(fields string) ([]string, error) {
+	var sections []string
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		section, ok := resolveSection(field)
+		if !ok {
+			return nil, fmt.Errorf("unknown section: %s", field)
+		}
+		sections = append(sections, section)
+	}
+	return sections, nil
+}
+
+// resolveSection maps a requested section name to a registered collector name
+func resolveSection(name string) (string, bool) {
+	if alias, exists := sectionAliases[name]; exists {
+		name = alias
+	}
+	if _, exists := system.GetCollector(name); !exists {
+		return "", false
+	}
+	return name, true
+}
+
+// writeSystemInfo writes system information as JSON, or a generic error if collection failed
+func writeSystemInfo(w http.ResponseWriter, info *system.SystemInfo, err error) {
+	if err != nil {
+		// Generic error message for production
+		writeJSONError(w, http.StatusInternalServerError, "Internal server error")
+		// Log detailed error server-side only
+		log.Printf("System info error: %v", err)
+		return
+	}
+
+	// Return system information as JSON
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	}
+}
+
+// writeJSONError writes an error message as a JSON response with the given status code
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{
+		"error": message,
+	}); err != nil {
+		http.Error(w, "Failed to encode error response", http.StatusInternalServerError)
+	}
+}
@@ -18,12 +18,14 @@ package main
 // along with this program. If not, see <https://www.gnu.org/licenses/>.
 
 import (
-	"encoding/json"
+	"context"
 	"glance-agent/auth"
+	"glance-agent/certs"
 	"glance-agent/env"
 	"glance-agent/system"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -36,52 +38,80 @@ func init() {
 	env.LoadConfig(Version) // Load environment variables from .env file
 }
 
-// sysinfoHandler handles requests for system information
-func sysinfoHandler(w http.ResponseWriter, _ *http.Request) {
-	// Get comprehensive system information
-	info, err := system.GetSystemInfo()
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		// Generic error message for production
-		if encodeErr := json.NewEncoder(w).Encode(map[string]string{
-			"error": "Internal server error",
-		}); encodeErr != nil {
-			http.Error(w, "Failed to encode error response", http.StatusInternalServerError)
-		}
-		// Log detailed error server-side only
-		log.Printf("System info error: %v", err)
-		return
-	}
-
-	// Return system information as JSON
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(info); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-	}
-}
-
 // main initializes and starts the HTTP server
 func main() {
+	// Run the subcommand instead of serving if one is given
+	switch env.GetCommand() {
+	case env.CommandCheck:
+		os.Exit(runCheck(os.Stdout))
+	case env.CommandOnce:
+		os.Exit(runOnce(os.Stdout, env.GetOutputFormat()))
+	}
+
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Throttle(10)) // 10 requests per second
 	r.Use(auth.LocalIPMiddleware)  // Restrict to local IPs only
 	r.Use(auth.SecurityMiddleware) // Add security middleware
 
+	// Limit every route except the live stream to 10 concurrent requests
+	throttle := middleware.Throttle(10)
+
 	// Protected API routes for system information
 	r.Route("/api/sysinfo", func(r chi.Router) {
-		r.Use(auth.Middleware(env.GetSecretToken())) // Pass the secret token
-		r.Get("/all", sysinfoHandler)
+		r.Use(auth.Middleware(env.ScopeSysinfoRead)) // Require a token with the sysinfo:read scope
+
+		// Streams stay open, so they are limited by STREAM_MAX_CLIENTS instead of the throttle
+		r.Get("/stream", streamHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(throttle)
+			r.Get("/all", sysinfoHandler)
+			r.Get("/history", historyHandler)
+			r.Get("/{section}", sectionHandler) // e.g. /cpu, /memory, /disks, /host, /thermal
+		})
 	})
 
+	// Protected HTML endpoint for the Glance extension widget
+	r.Route("/api/extension", func(r chi.Router) {
+		r.Use(throttle)
+		r.Use(auth.Middleware(env.ScopeSysinfoRead)) // Require a token with the sysinfo:read scope
+		r.Get("/", extensionHandler)
+		r.Get("/{template}", extensionHandler) // e.g. /api/extension/disks renders disks.html
+	})
+
+	// Protected Prometheus metrics endpoint
+	if env.GetMetricsEnabled() {
+		r.With(throttle, auth.Middleware(env.ScopeMetricsRead)).Get("/metrics", metricsHandler)
+	}
+
 	// Catch-all handler for undefined routes - drops connection
 	r.NotFound(auth.DropHandler)
 
+	// Start refreshing system information in the background
+	system.StartCollector(context.Background(), env.GetCollectInterval())
+
+	// Reload the configuration on SIGHUP and when the configuration files change
+	env.WatchConfig(context.Background())
+
 	log.Printf("Server starting on port %s", env.GetPort())
-	log.Printf("Configuration: token=%s", maskToken(env.GetSecretToken()))
-	log.Fatal(http.ListenAndServe(":"+env.GetPort(), r))
+	if env.GetSecretToken() != "" {
+		log.Printf("Configuration: token=%s", maskToken(env.GetSecretToken()))
+	}
+	log.Printf("Configuration: %d API tokens", len(env.GetTokens()))
+
+	server := &http.Server{Addr: ":" + env.GetPort(), Handler: r}
+	tlsOptions := env.GetTLSOptions()
+	if !tlsOptions.Enabled() {
+		log.Fatal(server.ListenAndServe())
+	}
+
+	tlsConfig, err := certs.NewServerConfig(tlsOptions)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %v", err)
+	}
+	server.TLSConfig = tlsConfig
+	log.Fatal(server.ListenAndServeTLS("", "")) // Certificates are served by the TLS configuration
 }
 
 // maskToken masks a token for logging purposes
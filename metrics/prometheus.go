@@ -0,0 +1,412 @@
+package metrics
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"glance-agent/system"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// namespace is prefixed to every exported metric name
+const namespace = "glance_agent_"
+
+// bytesPerMB converts the megabyte values in SystemInfo to bytes
+const bytesPerMB = 1024 * 1024
+
+// label is a single Prometheus label name/value pair
+type label struct {
+	name  string
+	value string
+}
+
+// encoder builds metric families in the Prometheus text format
+type encoder struct {
+	b strings.Builder
+}
+
+// header writes the HELP and TYPE header for a metric family
+func (e *encoder) header(name, help, metricType string) {
+	e.b.WriteString("# HELP " + namespace + name + " " + help + "\n")
+	e.b.WriteString("# TYPE " + namespace + name + " " + metricType + "\n")
+}
+
+// family writes the header for a gauge metric family
+func (e *encoder) family(name, help string) {
+	e.header(name, help, "gauge")
+}
+
+// counterFamily writes the header for a counter metric family
+func (e *encoder) counterFamily(name, help string) {
+	e.header(name, help, "counter")
+}
+
+// sample writes a single sample of a metric family
+func (e *encoder) sample(name string, value float64, labels ...label) {
+	e.b.WriteString(namespace + name)
+	if len(labels) > 0 {
+		e.b.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				e.b.WriteByte(',')
+			}
+			e.b.WriteString(l.name + `="` + escapeLabelValue(l.value) + `"`)
+		}
+		e.b.WriteByte('}')
+	}
+	e.b.WriteByte(' ')
+	e.b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	e.b.WriteByte('\n')
+}
+
+// gauge writes a metric family containing a single unlabelled sample
+func (e *encoder) gauge(name, help string, value float64) {
+	e.family(name, help)
+	e.sample(name, value)
+}
+
+// escapeLabelValue escapes backslashes, quotes and newlines in a label value
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// WritePrometheus renders system information in the Prometheus text exposition format.
+// Sections that are disabled or unavailable are omitted from the output.
+func WritePrometheus(w io.Writer, info *system.SystemInfo) error {
+	e := &encoder{}
+
+	degraded := 0.0
+	if info.Degraded {
+		degraded = 1
+	}
+	e.gauge("degraded", "Whether any section failed to collect", degraded)
+	if len(info.Errors) > 0 {
+		e.family("collector_error", "Section that failed to collect, value is always 1")
+		for _, name := range slices.Sorted(maps.Keys(info.Errors)) {
+			e.sample("collector_error", 1, label{"collector", name})
+		}
+	}
+
+	if info.HostInfoIsAvailable {
+		e.family("host_info", "Host information, value is always 1")
+		e.sample("host_info", 1,
+			label{"hostname", info.Hostname},
+			label{"platform", info.Platform},
+		)
+		e.gauge("boot_time_seconds", "System boot time as Unix timestamp", float64(info.BootTime))
+	}
+
+	if info.CPU.LoadIsAvailable {
+		e.gauge("cpu_load1_percent", "1-minute load average as percentage of CPU capacity", float64(info.CPU.Load1Percent))
+		e.gauge("cpu_load15_percent", "15-minute load average as percentage of CPU capacity", float64(info.CPU.Load15Percent))
+	}
+
+	if info.CPU.UsageIsAvailable {
+		e.family("cpu_usage_percent", "CPU utilisation by mode as percentage")
+		for _, usage := range append([]system.CPUUsage{info.CPU.Usage}, info.CPU.Cores...) {
+			for _, mode := range []struct {
+				name  string
+				value float64
+			}{
+				{"user", usage.UserPercent},
+				{"system", usage.SystemPercent},
+				{"iowait", usage.IOWaitPercent},
+				{"steal", usage.StealPercent},
+				{"idle", usage.IdlePercent},
+			} {
+				e.sample("cpu_usage_percent", mode.value, label{"cpu", usage.Name}, label{"mode", mode.name})
+			}
+		}
+	}
+
+	if info.CPU.TemperatureIsAvailable {
+		e.gauge("cpu_temperature_celsius", "CPU temperature in Celsius", float64(info.CPU.TemperatureC))
+	}
+
+	if info.Sensors.SensorsIsAvailable {
+		if len(info.Sensors.ThermalZones) > 0 {
+			e.family("thermal_zone_temperature_celsius", "Thermal zone temperature in Celsius")
+			for _, zone := range info.Sensors.ThermalZones {
+				e.sample("thermal_zone_temperature_celsius", zone.Temperature, label{"zone", zone.Name}, label{"type", zone.Type})
+			}
+		}
+		for _, kind := range []struct {
+			name     string
+			help     string
+			readings func(system.HwmonChip) []system.SensorReading
+		}{
+			{"sensor_temperature_celsius", "Hardware sensor temperature in Celsius", func(c system.HwmonChip) []system.SensorReading { return c.Temperatures }},
+			{"sensor_fan_rpm", "Hardware sensor fan speed in RPM", func(c system.HwmonChip) []system.SensorReading { return c.Fans }},
+			{"sensor_voltage_volts", "Hardware sensor voltage in volts", func(c system.HwmonChip) []system.SensorReading { return c.Voltages }},
+		} {
+			headerWritten := false
+			for _, chip := range info.Sensors.Hwmon {
+				for _, reading := range kind.readings(chip) {
+					if !headerWritten {
+						e.family(kind.name, kind.help)
+						headerWritten = true
+					}
+					e.sample(kind.name, reading.Value,
+						label{"chip", chip.Name},
+						label{"device", chip.Device},
+						label{"sensor", reading.Label},
+					)
+				}
+			}
+		}
+	}
+
+	if info.Memory.MemoryIsAvailable {
+		e.gauge("memory_total_bytes", "Total system memory in bytes", float64(info.Memory.TotalMB)*bytesPerMB)
+		e.gauge("memory_used_bytes", "Used system memory in bytes", float64(info.Memory.UsedMB)*bytesPerMB)
+		e.gauge("memory_used_percent", "Memory usage as percentage", float64(info.Memory.UsedPercent))
+	}
+
+	if info.Memory.SwapIsAvailable {
+		e.gauge("swap_total_bytes", "Total swap space in bytes", float64(info.Memory.SwapTotalMB)*bytesPerMB)
+		e.gauge("swap_used_bytes", "Used swap space in bytes", float64(info.Memory.SwapUsedMB)*bytesPerMB)
+		e.gauge("swap_used_percent", "Swap usage as percentage", float64(info.Memory.SwapUsedPercent))
+	}
+
+	if len(info.MountPoints) > 0 {
+		e.family("disk_total_bytes", "Total filesystem size in bytes")
+		for _, mp := range info.MountPoints {
+			e.sample("disk_total_bytes", float64(mp.TotalMB)*bytesPerMB, label{"path", mp.Path})
+		}
+		e.family("disk_used_bytes", "Used filesystem space in bytes")
+		for _, mp := range info.MountPoints {
+			e.sample("disk_used_bytes", float64(mp.UsedMB)*bytesPerMB, label{"path", mp.Path})
+		}
+		e.family("disk_used_percent", "Filesystem usage as percentage")
+		for _, mp := range info.MountPoints {
+			e.sample("disk_used_percent", float64(mp.UsedPercent), label{"path", mp.Path})
+		}
+		e.family("disk_inodes_total", "Total number of inodes on the filesystem")
+		for _, mp := range info.MountPoints {
+			if mp.InodesIsAvailable {
+				e.sample("disk_inodes_total", float64(mp.InodesTotal), label{"path", mp.Path})
+			}
+		}
+		e.family("disk_inodes_used", "Number of inodes in use on the filesystem")
+		for _, mp := range info.MountPoints {
+			if mp.InodesIsAvailable {
+				e.sample("disk_inodes_used", float64(mp.InodesUsed), label{"path", mp.Path})
+			}
+		}
+		e.family("disk_inodes_used_percent", "Inode usage as percentage")
+		for _, mp := range info.MountPoints {
+			if mp.InodesIsAvailable {
+				e.sample("disk_inodes_used_percent", float64(mp.InodesUsedPercent), label{"path", mp.Path})
+			}
+		}
+	}
+
+	if len(info.BlockDevices) > 0 {
+		devices := info.BlockDevices
+		for _, counter := range []struct {
+			name  string
+			help  string
+			value func(system.DiskIO) float64
+		}{
+			{"disk_read_bytes_total", "Total bytes read from the block device", func(d system.DiskIO) float64 { return float64(d.ReadBytes) }},
+			{"disk_written_bytes_total", "Total bytes written to the block device", func(d system.DiskIO) float64 { return float64(d.WriteBytes) }},
+			{"disk_reads_completed_total", "Total read operations completed", func(d system.DiskIO) float64 { return float64(d.ReadsCompleted) }},
+			{"disk_writes_completed_total", "Total write operations completed", func(d system.DiskIO) float64 { return float64(d.WritesCompleted) }},
+			{"disk_io_time_seconds_total", "Total time the block device was busy in seconds", func(d system.DiskIO) float64 { return float64(d.IOTimeMs) / 1000 }},
+		} {
+			e.counterFamily(counter.name, counter.help)
+			for _, device := range devices {
+				e.sample(counter.name, counter.value(device), label{"device", device.Device})
+			}
+		}
+		e.family("disk_io_util_percent", "Percentage of time the block device was busy since the previous collection")
+		for _, device := range devices {
+			e.sample("disk_io_util_percent", device.UtilPercent, label{"device", device.Device})
+		}
+	}
+
+	if info.Network.NetworkIsAvailable && len(info.Network.Interfaces) > 0 {
+		interfaces := info.Network.Interfaces
+		e.family("network_up", "Whether the network interface is operationally up")
+		for _, iface := range interfaces {
+			up := 0.0
+			if iface.State == "up" {
+				up = 1
+			}
+			e.sample("network_up", up, label{"interface", iface.Name})
+		}
+		e.family("network_speed_mbps", "Network interface link speed in megabits per second")
+		for _, iface := range interfaces {
+			e.sample("network_speed_mbps", float64(iface.SpeedMbps), label{"interface", iface.Name})
+		}
+		for _, counter := range []struct {
+			name  string
+			help  string
+			value func(system.NetworkInterface) uint64
+		}{
+			{"network_receive_bytes_total", "Total bytes received", func(i system.NetworkInterface) uint64 { return i.RxBytes }},
+			{"network_transmit_bytes_total", "Total bytes transmitted", func(i system.NetworkInterface) uint64 { return i.TxBytes }},
+			{"network_receive_packets_total", "Total packets received", func(i system.NetworkInterface) uint64 { return i.RxPackets }},
+			{"network_transmit_packets_total", "Total packets transmitted", func(i system.NetworkInterface) uint64 { return i.TxPackets }},
+			{"network_receive_errors_total", "Total receive errors", func(i system.NetworkInterface) uint64 { return i.RxErrors }},
+			{"network_transmit_errors_total", "Total transmit errors", func(i system.NetworkInterface) uint64 { return i.TxErrors }},
+			{"network_receive_drop_total", "Total received packets dropped", func(i system.NetworkInterface) uint64 { return i.RxDropped }},
+			{"network_transmit_drop_total", "Total transmitted packets dropped", func(i system.NetworkInterface) uint64 { return i.TxDropped }},
+		} {
+			e.counterFamily(counter.name, counter.help)
+			for _, iface := range interfaces {
+				e.sample(counter.name, float64(counter.value(iface)), label{"interface", iface.Name})
+			}
+		}
+	}
+
+	if info.Pressure.PressureIsAvailable {
+		e.pressure("cpu_pressure", "CPU", info.Pressure.CPU)
+		e.pressure("memory_pressure", "memory", info.Pressure.Memory)
+		e.pressure("io_pressure", "I/O", info.Pressure.IO)
+	}
+
+	if info.Processes.ProcessesIsAvailable {
+		e.gauge("processes", "Number of processes", float64(info.Processes.Total))
+		processLabels := func(p system.Process) []label {
+			return []label{{"pid", strconv.Itoa(p.PID)}, {"name", p.Name}, {"user", p.User}}
+		}
+		e.family("top_process_cpu_percent", "CPU usage of the processes using the most CPU as percentage of all cores")
+		for _, process := range info.Processes.TopCPU {
+			e.sample("top_process_cpu_percent", process.CPUPercent, processLabels(process)...)
+		}
+		e.family("top_process_resident_memory_bytes", "Resident memory of the processes using the most memory in bytes")
+		for _, process := range info.Processes.TopMemory {
+			e.sample("top_process_resident_memory_bytes", float64(process.RSSMB)*bytesPerMB, processLabels(process)...)
+		}
+	}
+
+	if info.Containers.ContainersIsAvailable {
+		containers := info.Containers.Containers
+		containerLabels := func(c system.Container) []label {
+			return []label{{"name", c.Name}, {"id", c.ID}, {"image", c.Image}}
+		}
+		e.family("container_running", "Whether the container is running")
+		for _, container := range containers {
+			running := 0.0
+			if container.State == "running" {
+				running = 1
+			}
+			e.sample("container_running", running, containerLabels(container)...)
+		}
+		e.family("container_healthy", "Whether the health check of the container passes")
+		for _, container := range containers {
+			if container.Health != "" {
+				healthy := 0.0
+				if container.Health == "healthy" {
+					healthy = 1
+				}
+				e.sample("container_healthy", healthy, containerLabels(container)...)
+			}
+		}
+		e.counterFamily("container_restarts_total", "Total restarts of the container")
+		for _, container := range containers {
+			e.sample("container_restarts_total", float64(container.RestartCount), containerLabels(container)...)
+		}
+		e.family("container_uptime_seconds", "Time since the container started in seconds")
+		for _, container := range containers {
+			if container.State == "running" {
+				e.sample("container_uptime_seconds", float64(container.UptimeSeconds), containerLabels(container)...)
+			}
+		}
+		for _, metric := range []struct {
+			name  string
+			help  string
+			value func(system.Container) float64
+		}{
+			{"container_cpu_percent", "CPU usage of the container as percentage of all cores", func(c system.Container) float64 { return c.CPUPercent }},
+			{"container_memory_used_bytes", "Memory used by the container in bytes, excluding inactive page cache", func(c system.Container) float64 { return float64(c.MemoryUsedMB) * bytesPerMB }},
+			{"container_memory_limit_bytes", "Memory limit of the container in bytes", func(c system.Container) float64 { return float64(c.MemoryLimitMB) * bytesPerMB }},
+		} {
+			e.family(metric.name, metric.help)
+			for _, container := range containers {
+				if container.StatsIsAvailable {
+					e.sample(metric.name, metric.value(container), containerLabels(container)...)
+				}
+			}
+		}
+		for _, counter := range []struct {
+			name  string
+			help  string
+			value func(system.Container) uint64
+		}{
+			{"container_network_receive_bytes_total", "Total bytes received by the container", func(c system.Container) uint64 { return c.NetworkRxBytes }},
+			{"container_network_transmit_bytes_total", "Total bytes transmitted by the container", func(c system.Container) uint64 { return c.NetworkTxBytes }},
+		} {
+			e.counterFamily(counter.name, counter.help)
+			for _, container := range containers {
+				if container.StatsIsAvailable {
+					e.sample(counter.name, float64(counter.value(container)), containerLabels(container)...)
+				}
+			}
+		}
+	}
+
+	if info.Cgroup.CgroupIsAvailable {
+		cgroup := info.Cgroup
+		if cgroup.MemoryLimitIsSet {
+			e.gauge("cgroup_memory_limit_bytes", "Memory limit of the agent's cgroup in bytes", float64(cgroup.MemoryLimitMB)*bytesPerMB)
+		}
+		e.gauge("cgroup_memory_used_bytes", "Memory used by the agent's cgroup in bytes, excluding inactive page cache", float64(cgroup.MemoryUsedMB)*bytesPerMB)
+		e.gauge("cgroup_memory_used_percent", "Memory usage of the agent's cgroup as percentage of its limit", float64(cgroup.MemoryUsedPercent))
+		if cgroup.CPULimitIsSet {
+			e.gauge("cgroup_cpu_limit_cores", "CPU quota of the agent's cgroup in cores", cgroup.CPULimitCores)
+		}
+		e.gauge("cgroup_cpu_usage_percent", "CPU usage of the agent's cgroup as percentage of its quota", cgroup.CPUUsagePercent)
+		e.counterFamily("cgroup_cpu_periods_total", "Total CPU quota enforcement periods of the agent's cgroup")
+		e.sample("cgroup_cpu_periods_total", float64(cgroup.CPUPeriods))
+		e.counterFamily("cgroup_cpu_throttled_periods_total", "Total periods in which the agent's cgroup was throttled")
+		e.sample("cgroup_cpu_throttled_periods_total", float64(cgroup.CPUThrottledPeriods))
+		e.counterFamily("cgroup_cpu_throttled_seconds_total", "Total time the agent's cgroup was throttled in seconds")
+		e.sample("cgroup_cpu_throttled_seconds_total", float64(cgroup.CPUThrottledMs)/1000)
+		if cgroup.MemoryPressureIsAvailable {
+			e.pressure("cgroup_memory_pressure", "memory in the agent's cgroup", cgroup.MemoryPressure)
+		}
+	}
+
+	_, err := io.WriteString(w, e.b.String())
+	return err
+}
+
+// pressure writes the stall averages and total stall time of Pressure Stall Information
+func (e *encoder) pressure(name, help string, p system.Pressure) {
+	lines := []struct {
+		kind  string
+		stats system.PressureStats
+	}{{"some", p.Some}, {"full", p.Full}}
+
+	e.family(name+"_percent", "Percentage of time stalled on "+help+" over the window")
+	for _, line := range lines {
+		e.sample(name+"_percent", line.stats.Avg10, label{"kind", line.kind}, label{"window", "10s"})
+		e.sample(name+"_percent", line.stats.Avg60, label{"kind", line.kind}, label{"window", "60s"})
+		e.sample(name+"_percent", line.stats.Avg300, label{"kind", line.kind}, label{"window", "300s"})
+	}
+	e.counterFamily(name+"_stall_seconds_total", "Total time stalled on "+help+" in seconds")
+	for _, line := range lines {
+		e.sample(name+"_stall_seconds_total", float64(line.stats.TotalUs)/1e6, label{"kind", line.kind})
+	}
+}
@@ -0,0 +1,232 @@
+//go:build linux || windows
+
+package main
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"glance-agent/metrics"
+	"glance-agent/system"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats of the once subcommand
+const (
+	formatJSON       = "json"
+	formatText       = "text"
+	formatPrometheus = "prometheus"
+)
+
+// onceSampleInterval is how long the once subcommand samples counters, so CPU utilisation
+// and rates cover this interval instead of the time since boot
+const onceSampleInterval = time.Second
+
+// runOnce collects system information a single time and prints it for the once subcommand
+// Returns the exit code.
+func runOnce(out io.Writer, format string) int {
+	if format != formatJSON && format != formatText && format != formatPrometheus {
+		fmt.Fprintf(os.Stderr, "Unknown output format %q, expected json, text or prometheus\n", format)
+		return 2
+	}
+
+	// The first collection only primes the counters used for utilisation and rates
+	ctx := context.Background()
+	if _, err := system.CollectSystemInfo(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to collect system information: %v\n", err)
+		return 1
+	}
+	time.Sleep(onceSampleInterval)
+	info, err := system.CollectSystemInfo(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to collect system information: %v\n", err)
+		return 1
+	}
+
+	switch format {
+	case formatText:
+		err = writeText(out, info)
+	case formatPrometheus:
+		err = metrics.WritePrometheus(out, info)
+	default:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(info)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write system information: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeText prints system information as human readable text, skipping unavailable sections
+func writeText(out io.Writer, info *system.SystemInfo) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	if info.HostInfoIsAvailable {
+		uptime := time.Since(time.Unix(info.BootTime, 0)).Truncate(time.Minute)
+		fmt.Fprintf(w, "Host:\t%s (%s), up %s\n", info.Hostname, info.Platform, uptime)
+	}
+	fmt.Fprintf(w, "Collected:\t%s\n", time.Unix(info.CollectedAt, 0).Format(time.DateTime))
+
+	if info.CPU.UsageIsAvailable {
+		usage := info.CPU.Usage
+		fmt.Fprintf(w, "CPU usage:\t%.1f%% (user %.1f%%, system %.1f%%, iowait %.1f%%, steal %.1f%%)\n",
+			usage.UsedPercent, usage.UserPercent, usage.SystemPercent, usage.IOWaitPercent, usage.StealPercent)
+	}
+	if info.CPU.LoadIsAvailable {
+		fmt.Fprintf(w, "CPU load:\t%d%% (1m), %d%% (15m)\n", info.CPU.Load1Percent, info.CPU.Load15Percent)
+	}
+	if info.CPU.TemperatureIsAvailable {
+		fmt.Fprintf(w, "CPU temperature:\t%d°C\n", info.CPU.TemperatureC)
+	}
+	if info.Memory.MemoryIsAvailable {
+		fmt.Fprintf(w, "Memory:\t%d MB / %d MB (%d%%)\n", info.Memory.UsedMB, info.Memory.TotalMB, info.Memory.UsedPercent)
+	}
+	if info.Memory.SwapIsAvailable {
+		fmt.Fprintf(w, "Swap:\t%d MB / %d MB (%d%%)\n", info.Memory.SwapUsedMB, info.Memory.SwapTotalMB, info.Memory.SwapUsedPercent)
+	}
+
+	if info.Pressure.PressureIsAvailable {
+		pressure := info.Pressure
+		fmt.Fprintf(w, "Pressure (10s):\tcpu some %.2f%%, memory some %.2f%% full %.2f%%, io some %.2f%% full %.2f%%\n",
+			pressure.CPU.Some.Avg10, pressure.Memory.Some.Avg10, pressure.Memory.Full.Avg10, pressure.IO.Some.Avg10, pressure.IO.Full.Avg10)
+	}
+	if info.Cgroup.CgroupIsAvailable {
+		cgroup := info.Cgroup
+		fmt.Fprintf(w, "Cgroup:\t%s (v%d)\n", cgroup.Path, cgroup.Version)
+		memoryLimit, cpuLimit := "system memory", "all cores"
+		if cgroup.MemoryLimitIsSet {
+			memoryLimit = fmt.Sprintf("%d MB", cgroup.MemoryLimitMB)
+		}
+		if cgroup.CPULimitIsSet {
+			cpuLimit = fmt.Sprintf("%.2f cores", cgroup.CPULimitCores)
+		}
+		fmt.Fprintf(w, "Cgroup memory:\t%d MB / %s (%d%%)\n", cgroup.MemoryUsedMB, memoryLimit, cgroup.MemoryUsedPercent)
+		fmt.Fprintf(w, "Cgroup CPU:\t%.1f%% of %s, throttled %d of %d periods (%d ms)\n",
+			cgroup.CPUUsagePercent, cpuLimit, cgroup.CPUThrottledPeriods, cgroup.CPUPeriods, cgroup.CPUThrottledMs)
+		if cgroup.MemoryPressureIsAvailable {
+			fmt.Fprintf(w, "Cgroup memory pressure:\tsome %.2f%%, full %.2f%% (10s)\n",
+				cgroup.MemoryPressure.Some.Avg10, cgroup.MemoryPressure.Full.Avg10)
+		}
+	}
+
+	if len(info.MountPoints) > 0 {
+		fmt.Fprintln(w, "\nMountpoints:")
+		for _, mount := range info.MountPoints {
+			line := fmt.Sprintf("  %s\t%d MB / %d MB (%d%%)", mount.Path, mount.UsedMB, mount.TotalMB, mount.UsedPercent)
+			if mount.InodesIsAvailable {
+				line += fmt.Sprintf("\tinodes %d%%", mount.InodesUsedPercent)
+			}
+			if mount.IOIsAvailable {
+				line += fmt.Sprintf("\tread %s/s, write %s/s", formatBytes(mount.IO.ReadBytesPerSec), formatBytes(mount.IO.WriteBytesPerSec))
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	if info.Network.NetworkIsAvailable && len(info.Network.Interfaces) > 0 {
+		fmt.Fprintln(w, "\nNetwork:")
+		for _, iface := range info.Network.Interfaces {
+			speed := "unknown speed"
+			if iface.SpeedMbps > 0 {
+				speed = fmt.Sprintf("%d Mbps", iface.SpeedMbps)
+			}
+			fmt.Fprintf(w, "  %s\t%s, %s\trx %s/s, tx %s/s\n", iface.Name, iface.State, speed,
+				formatBytes(iface.RxBytesPerSec), formatBytes(iface.TxBytesPerSec))
+		}
+	}
+
+	if info.Processes.ProcessesIsAvailable {
+		for _, list := range []struct {
+			title     string
+			processes []system.Process
+		}{
+			{"Top processes by CPU", info.Processes.TopCPU},
+			{"Top processes by memory", info.Processes.TopMemory},
+		} {
+			fmt.Fprintf(w, "\n%s:\n", list.title)
+			for _, process := range list.processes {
+				fmt.Fprintf(w, "  %d\t%s\t%s\t%.1f%%\t%d MB\t%s\n", process.PID, process.User, process.Name,
+					process.CPUPercent, process.RSSMB, process.CommandLine)
+			}
+		}
+	}
+
+	if info.Containers.ContainersIsAvailable && len(info.Containers.Containers) > 0 {
+		fmt.Fprintln(w, "\nContainers:")
+		for _, container := range info.Containers.Containers {
+			state := container.State
+			if container.Health != "" {
+				state += " (" + container.Health + ")"
+			}
+			line := fmt.Sprintf("  %s\t%s\t%s", container.Name, container.Image, state)
+			if container.StatsIsAvailable {
+				line += fmt.Sprintf("\t%.1f%%\t%d MB of %d MB\trx %s/s, tx %s/s", container.CPUPercent,
+					container.MemoryUsedMB, container.MemoryLimitMB,
+					formatBytes(container.NetworkRxBytesPerSec), formatBytes(container.NetworkTxBytesPerSec))
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+
+	if info.Sensors.SensorsIsAvailable {
+		fmt.Fprintln(w, "\nSensors:")
+		for _, zone := range info.Sensors.ThermalZones {
+			fmt.Fprintf(w, "  %s\t%s\t%.1f°C\n", zone.Name, zone.Type, zone.Temperature)
+		}
+		for _, chip := range info.Sensors.Hwmon {
+			for _, reading := range chip.Temperatures {
+				fmt.Fprintf(w, "  %s\t%s\t%.1f°C\n", chip.Name, reading.Label, reading.Value)
+			}
+			for _, reading := range chip.Fans {
+				fmt.Fprintf(w, "  %s\t%s\t%.0f RPM\n", chip.Name, reading.Label, reading.Value)
+			}
+			for _, reading := range chip.Voltages {
+				fmt.Fprintf(w, "  %s\t%s\t%.2f V\n", chip.Name, reading.Label, reading.Value)
+			}
+		}
+	}
+
+	if len(info.Errors) > 0 {
+		fmt.Fprintln(w, "\nErrors:")
+		for _, section := range slices.Sorted(maps.Keys(info.Errors)) {
+			fmt.Fprintf(w, "  %s\t%s\n", section, strings.TrimSpace(info.Errors[section]))
+		}
+	}
+
+	return w.Flush()
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. 1.5 MiB
+func formatBytes(bytes float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	unit := 0
+	for bytes >= 1024 && unit < len(units)-1 {
+		bytes /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %s", bytes, units[unit])
+}
@@ -0,0 +1,190 @@
+//go:build linux || windows
+
+package main
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"glance-agent/env"
+	"glance-agent/system"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Stream modes selected with the mode query parameter
+const (
+	streamModeFull    = "full"    // Every event holds the whole snapshot
+	streamModeChanges = "changes" // After the first snapshot, events only hold the fields that changed
+)
+
+// streamKeepAlive is how often a comment is sent while nothing changes, so proxies keep the
+// connection open and disconnected clients are noticed
+const streamKeepAlive = 30 * time.Second
+
+// activeStreams counts the open streams
+var activeStreams atomic.Int32
+
+// streamHandler sends system information as Server-Sent Events until the client disconnects
+// Query parameters:
+//   - interval: time between events, e.g. "5s" or "5", limited to the configured bounds
+//   - mode: "full" sends the whole snapshot every time, "changes" only the fields that changed
+func streamHandler(w http.ResponseWriter, r *http.Request) {
+	options := env.GetStreamOptions()
+
+	var requested time.Duration
+	if value := r.URL.Query().Get("interval"); value != "" {
+		var err error
+		if requested, err = parseStreamInterval(value); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "Invalid interval")
+			return
+		}
+	}
+	interval := options.Interval(requested)
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = streamModeFull
+	}
+	if mode != streamModeFull && mode != streamModeChanges {
+		writeJSONError(w, http.StatusBadRequest, "Invalid mode, expected full or changes")
+		return
+	}
+
+	// Reserve a stream slot, released when the client disconnects
+	if int(activeStreams.Add(1)) > options.MaxClients {
+		activeStreams.Add(-1)
+		w.Header().Set("Retry-After", strconv.Itoa(int(options.MaxInterval.Seconds())))
+		writeJSONError(w, http.StatusServiceUnavailable, "Too many streams")
+		return
+	}
+	defer activeStreams.Add(-1)
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	// Ask the client to wait one interval before reconnecting
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", interval.Milliseconds()); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var previous map[string]json.RawMessage
+	lastWrite := time.Now()
+	for {
+		// Snapshots up to half an interval old are reused, so streams share collections
+		info, err := system.GetFreshSnapshot(r.Context(), interval/2)
+		if err != nil {
+			if r.Context().Err() == nil {
+				log.Printf("System info error: %v", err)
+			}
+			return
+		}
+
+		event, payload, current, err := streamEvent(info, previous, mode)
+		if err != nil {
+			log.Printf("Failed to encode stream event: %v", err)
+			return
+		}
+		previous = current
+
+		switch {
+		case payload != nil:
+			_, err = fmt.Fprintf(w, "event: %s\nid: %d\ndata: %s\n\n", event, info.CollectedAt, payload)
+			lastWrite = time.Now()
+		case time.Since(lastWrite) >= streamKeepAlive:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+			lastWrite = time.Now()
+		}
+		if err == nil {
+			err = controller.Flush()
+		}
+		if err != nil {
+			return // The client disconnected
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// streamEvent returns the event name and data to send for a snapshot, and the encoded fields to
+// compare the next snapshot with. The data is nil when nothing changed in changes mode.
+func streamEvent(info *system.SystemInfo, previous map[string]json.RawMessage, mode string) (string, []byte, map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(info)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if mode == streamModeFull {
+		return "snapshot", encoded, nil, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return "", nil, nil, err
+	}
+	if previous == nil {
+		return "snapshot", encoded, fields, nil // The first event holds everything
+	}
+
+	changed := make(map[string]json.RawMessage)
+	for name, value := range fields {
+		if name != "collected_at" && !bytes.Equal(previous[name], value) {
+			changed[name] = value
+		}
+	}
+	// Fields that are omitted when empty, such as errors, are sent as null when they disappear
+	for name := range previous {
+		if _, exists := fields[name]; !exists {
+			changed[name] = json.RawMessage("null")
+		}
+	}
+	if len(changed) == 0 {
+		return "", nil, fields, nil
+	}
+
+	changed["collected_at"] = fields["collected_at"]
+	data, err := json.Marshal(changed)
+	return "changes", data, fields, err
+}
+
+// parseStreamInterval parses an interval such as "5s", a bare number is treated as seconds
+func parseStreamInterval(value string) (time.Duration, error) {
+	interval, err := time.ParseDuration(value)
+	if seconds, convErr := strconv.ParseFloat(value, 64); convErr == nil {
+		interval, err = time.Duration(seconds*float64(time.Second)), nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("interval must be positive")
+	}
+	return interval, nil
+}
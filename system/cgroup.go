@@ -0,0 +1,50 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "context"
+
+func init() {
+	RegisterCollector(cgroupCollector{})
+}
+
+// cgroupCollector gathers the limits and usage of the cgroup the agent runs in
+type cgroupCollector struct{}
+
+// Name returns the section name
+func (cgroupCollector) Name() string {
+	return "cgroup"
+}
+
+// Enabled reports whether the cgroup-aware mode is enabled
+func (cgroupCollector) Enabled() bool {
+	return getSettings().CgroupAware
+}
+
+// Collect gathers the cgroup limits and usage into info.Cgroup
+func (cgroupCollector) Collect(_ context.Context, info *SystemInfo) error {
+	cgroup, err := getCgroupInfo()
+	if err != nil {
+		return err
+	}
+	info.Cgroup = cgroup
+	return nil
+}
+
+// CopySection copies the cgroup limits and usage from src into dst
+func (cgroupCollector) CopySection(dst, src *SystemInfo) {
+	dst.Cgroup = src.Cgroup
+}
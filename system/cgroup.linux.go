@@ -0,0 +1,477 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cgroupV1Unlimited is the smallest cgroup v1 memory limit treated as unlimited
+// An unlimited v1 memory limit reads as the largest page-aligned 64-bit value.
+const cgroupV1Unlimited = 1 << 62
+
+// cgroupMount is a mounted cgroup hierarchy
+type cgroupMount struct {
+	root       string // Path of the mounted part of the hierarchy, e.g. "/" or the container's cgroup
+	mountpoint string // Where the hierarchy is mounted, e.g. "/sys/fs/cgroup/memory"
+}
+
+// dir returns the directory of a cgroup path within the mounted hierarchy
+func (m cgroupMount) dir(path string) string {
+	// Containers and cgroup namespaces only mount their own part of the hierarchy
+	relative, err := filepath.Rel(m.root, path)
+	if err != nil || strings.HasPrefix(relative, "..") {
+		return m.mountpoint
+	}
+	return filepath.Join(m.mountpoint, relative)
+}
+
+// cgroupDirs holds the controller directories of the agent's cgroup
+type cgroupDirs struct {
+	version int    // cgroup version, 1 or 2
+	path    string // cgroup path of the memory controller, or of the unified hierarchy
+	memory  string // Directory of the memory controller, empty if it is not mounted
+	cpu     string // Directory of the cpu controller, empty if it is not mounted
+	cpuacct string // Directory of the cpuacct controller (cgroup v1 only)
+}
+
+// cgroupMemory holds the memory limit and usage of a cgroup in bytes
+type cgroupMemory struct {
+	limit uint64 // Memory limit, 0 when unlimited
+	used  uint64 // Memory in use, excluding inactive page cache
+}
+
+// cgroupCPU holds the CPU quota and counters of a cgroup
+type cgroupCPU struct {
+	quotaCores       float64 // CPU quota in cores, 0 when unlimited
+	usageUs          uint64  // Total CPU time consumed in microseconds
+	periods          uint64  // Total quota enforcement periods
+	throttledPeriods uint64  // Total periods in which the cgroup was throttled
+	throttledUs      uint64  // Total time the cgroup was throttled in microseconds
+}
+
+// cgroupSampler keeps the previous CPU usage reading so utilisation can be computed from deltas
+var cgroupSampler = struct {
+	sync.Mutex
+	usageUs uint64
+	at      time.Time
+}{}
+
+// getCgroupInfo reads the limits and usage of the agent's cgroup
+func getCgroupInfo() (CgroupInfo, error) {
+	dirs, err := findCgroupDirs()
+	if err != nil {
+		return CgroupInfo{}, err
+	}
+	info := CgroupInfo{Version: dirs.version, Path: dirs.path}
+
+	memory, err := readCgroupMemory(dirs)
+	if err != nil {
+		return CgroupInfo{}, err
+	}
+	systemMemory, err := readSystemMemory()
+	if err != nil {
+		return CgroupInfo{}, err
+	}
+	available := systemMemory
+	if memory.limit > 0 && memory.limit < systemMemory {
+		available = memory.limit
+		info.MemoryLimitIsSet = true
+		info.MemoryLimitMB = int(memory.limit / (1024 * 1024))
+	}
+	info.MemoryUsedMB = int(memory.used / (1024 * 1024))
+	if available > 0 {
+		info.MemoryUsedPercent = int(memory.used * 100 / available)
+	}
+
+	cpu, err := readCgroupCPU(dirs)
+	if err != nil {
+		return CgroupInfo{}, err
+	}
+	cores := float64(runtime.NumCPU())
+	if cpu.quotaCores > 0 && cpu.quotaCores < cores {
+		cores = cpu.quotaCores
+		info.CPULimitIsSet = true
+		info.CPULimitCores = cpu.quotaCores
+	}
+	info.CPUUsagePercent = sampleCgroupCPUUsage(cpu.usageUs, cores)
+	info.CPUPeriods = cpu.periods
+	info.CPUThrottledPeriods = cpu.throttledPeriods
+	info.CPUThrottledMs = cpu.throttledUs / 1000
+
+	// Pressure Stall Information is only kept per cgroup in cgroup v2
+	if dirs.version == 2 {
+		if pressure, err := readPressure(filepath.Join(dirs.memory, "memory.pressure")); err == nil {
+			info.MemoryPressureIsAvailable = true
+			info.MemoryPressure = pressure
+		}
+	}
+
+	info.CgroupIsAvailable = true
+	return info, nil
+}
+
+// applyCgroupMemoryLimit reports memory against the memory limit of the agent's cgroup
+// The memory section keeps the system values when the cgroup has no limit or cannot be
+// read; the cgroup section reports the error in that case.
+func applyCgroupMemoryLimit(memoryInfo *MemoryInfo) {
+	dirs, err := findCgroupDirs()
+	if err != nil {
+		return
+	}
+	memory, err := readCgroupMemory(dirs)
+	if err != nil || memory.limit == 0 {
+		return
+	}
+
+	limitMB := int(memory.limit / (1024 * 1024))
+	if limitMB <= 0 || limitMB >= memoryInfo.TotalMB {
+		return
+	}
+	memoryInfo.TotalMB = limitMB
+	memoryInfo.UsedMB = int(memory.used / (1024 * 1024))
+	memoryInfo.UsedPercent = (memoryInfo.UsedMB * 100) / limitMB
+}
+
+// effectiveCPUCount returns the number of CPUs load averages are divided by
+// In cgroup-aware mode a CPU quota smaller than the number of CPUs takes its place.
+func effectiveCPUCount() float64 {
+	cpuCount := float64(runtime.NumCPU())
+	if !getSettings().CgroupAware {
+		return cpuCount
+	}
+
+	dirs, err := findCgroupDirs()
+	if err != nil {
+		return cpuCount
+	}
+	quota, err := readCgroupCPUQuota(dirs)
+	if err != nil || quota == 0 {
+		return cpuCount
+	}
+	return min(quota, cpuCount)
+}
+
+// sampleCgroupCPUUsage returns the CPU usage as percentage of the available cores since the previous call
+// Returns 0 on the first call.
+func sampleCgroupCPUUsage(usageUs uint64, cores float64) float64 {
+	now := time.Now()
+
+	cgroupSampler.Lock()
+	previousUsage, previousAt := cgroupSampler.usageUs, cgroupSampler.at
+	cgroupSampler.usageUs, cgroupSampler.at = usageUs, now
+	cgroupSampler.Unlock()
+
+	elapsedUs := float64(now.Sub(previousAt).Microseconds())
+	if previousAt.IsZero() || usageUs < previousUsage || elapsedUs <= 0 {
+		return 0
+	}
+	percent := float64(usageUs-previousUsage) / (elapsedUs * cores) * 100
+	return math.Round(percent*100) / 100 // Round to 2 decimal places
+}
+
+// findCgroupDirs locates the controller directories of the agent's cgroup
+// The cgroup of the agent itself is used, so these are always read from /proc/self
+// rather than the host's /proc.
+func findCgroupDirs() (cgroupDirs, error) {
+	paths, err := readSelfCgroup()
+	if err != nil {
+		return cgroupDirs{}, err
+	}
+	mounts, err := readCgroupMounts()
+	if err != nil {
+		return cgroupDirs{}, err
+	}
+
+	// cgroup v1, including hybrid setups where the unified hierarchy has no controllers
+	_, hasMemory := paths["memory"]
+	_, hasCPU := paths["cpu"]
+	if hasMemory || hasCPU {
+		dirs := cgroupDirs{version: 1, path: paths["memory"]}
+		if dirs.path == "" {
+			dirs.path = paths["cpu"]
+		}
+		for controller, dir := range map[string]*string{"memory": &dirs.memory, "cpu": &dirs.cpu, "cpuacct": &dirs.cpuacct} {
+			if mount, exists := mounts[controller]; exists {
+				*dir = mount.dir(paths[controller])
+			}
+		}
+		return dirs, nil
+	}
+
+	// cgroup v2, where every controller shares the unified hierarchy
+	path, hasUnified := paths[""]
+	mount, isMounted := mounts[""]
+	if !hasUnified || !isMounted {
+		return cgroupDirs{}, errors.New("no cgroup hierarchy found for the agent")
+	}
+	dir := mount.dir(path)
+	return cgroupDirs{version: 2, path: path, memory: dir, cpu: dir}, nil
+}
+
+// readSelfCgroup returns the cgroup path of the agent keyed by controller
+// The unified cgroup v2 hierarchy has an empty controller name.
+func readSelfCgroup() (map[string]string, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		// /proc/self/cgroup format: "hierarchy-id:controllers:path"
+		// e.g. "4:memory:/docker/abc" for cgroup v1 or "0::/system.slice/glance-agent.service" for v2
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[1] == "" {
+			paths[""] = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			paths[controller] = fields[2]
+		}
+	}
+	return paths, nil
+}
+
+// readCgroupMounts returns the mounted cgroup hierarchies keyed by controller
+// The unified cgroup v2 hierarchy has an empty controller name.
+func readCgroupMounts() (map[string]cgroupMount, error) {
+	file, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			fmt.Fprintf(os.Stderr, "error closing file: %v\n", cerr)
+		}
+	}()
+
+	mounts := make(map[string]cgroupMount)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// /proc/self/mountinfo format:
+		// "33 32 0:29 / /sys/fs/cgroup/memory rw,relatime - cgroup cgroup rw,memory"
+		// The fields after " - " are the filesystem type, source and superblock options
+		mountFields, superFields, found := strings.Cut(scanner.Text(), " - ")
+		if !found {
+			continue
+		}
+		fields, super := strings.Fields(mountFields), strings.Fields(superFields)
+		if len(fields) < 5 || len(super) < 3 {
+			continue
+		}
+		mount := cgroupMount{root: fields[3], mountpoint: fields[4]}
+
+		switch super[0] {
+		case "cgroup2":
+			if _, exists := mounts[""]; !exists {
+				mounts[""] = mount
+			}
+		case "cgroup":
+			// The superblock options of a v1 hierarchy name its controllers
+			for _, controller := range strings.Split(super[2], ",") {
+				if _, exists := mounts[controller]; !exists {
+					mounts[controller] = mount
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return mounts, nil
+}
+
+// readCgroupMemory reads the memory limit and usage of a cgroup
+func readCgroupMemory(dirs cgroupDirs) (cgroupMemory, error) {
+	if dirs.memory == "" {
+		return cgroupMemory{}, errors.New("the memory cgroup controller is not mounted")
+	}
+
+	limitFile, usageFile, inactiveKey := "memory.max", "memory.current", "inactive_file"
+	if dirs.version == 1 {
+		limitFile, usageFile, inactiveKey = "memory.limit_in_bytes", "memory.usage_in_bytes", "total_inactive_file"
+	}
+
+	var memory cgroupMemory
+	limit, err := readCgroupValue(filepath.Join(dirs.memory, limitFile))
+	if dirs.version == 2 && errors.Is(err, os.ErrNotExist) {
+		limit = math.MaxUint64 // The root cgroup has no limit
+	} else if err != nil {
+		return cgroupMemory{}, err
+	}
+	if limit < cgroupV1Unlimited {
+		memory.limit = limit
+	}
+
+	usage, err := readCgroupValue(filepath.Join(dirs.memory, usageFile))
+	if err != nil {
+		return cgroupMemory{}, err
+	}
+
+	// Inactive page cache can be reclaimed, so it is not counted as used like in docker stats
+	stat, err := readCgroupStat(filepath.Join(dirs.memory, "memory.stat"))
+	if err != nil {
+		return cgroupMemory{}, err
+	}
+	memory.used = usage
+	if inactive := stat[inactiveKey]; inactive < usage {
+		memory.used = usage - inactive
+	}
+
+	return memory, nil
+}
+
+// readCgroupCPUQuota returns the CPU quota of a cgroup in cores, 0 when unlimited
+func readCgroupCPUQuota(dirs cgroupDirs) (float64, error) {
+	if dirs.cpu == "" {
+		return 0, errors.New("the cpu cgroup controller is not mounted")
+	}
+
+	if dirs.version == 2 {
+		// cpu.max format: "quota period", where quota is "max" when unlimited
+		data, err := os.ReadFile(filepath.Join(dirs.cpu, "cpu.max"))
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil // The root cgroup has no quota
+		} else if err != nil {
+			return 0, err
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) != 2 {
+			return 0, fmt.Errorf("invalid cpu.max format")
+		}
+		if fields[0] == "max" {
+			return 0, nil
+		}
+		quota, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cpu.max quota: %w", err)
+		}
+		period, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || period <= 0 {
+			return 0, fmt.Errorf("invalid cpu.max period %q", fields[1])
+		}
+		return quota / period, nil
+	}
+
+	// cgroup v1 keeps the quota and period in separate files, a quota of -1 is unlimited
+	data, err := os.ReadFile(filepath.Join(dirs.cpu, "cpu.cfs_quota_us"))
+	if err != nil {
+		return 0, err
+	}
+	quota, err := strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpu.cfs_quota_us value: %w", err)
+	}
+	if quota <= 0 {
+		return 0, nil
+	}
+	period, err := readCgroupValue(filepath.Join(dirs.cpu, "cpu.cfs_period_us"))
+	if err != nil {
+		return 0, err
+	}
+	if period == 0 {
+		return 0, fmt.Errorf("invalid cpu.cfs_period_us value 0")
+	}
+	return quota / float64(period), nil
+}
+
+// readCgroupCPU reads the CPU quota, usage and throttling counters of a cgroup
+func readCgroupCPU(dirs cgroupDirs) (cgroupCPU, error) {
+	quota, err := readCgroupCPUQuota(dirs)
+	if err != nil {
+		return cgroupCPU{}, err
+	}
+	cpu := cgroupCPU{quotaCores: quota}
+
+	stat, err := readCgroupStat(filepath.Join(dirs.cpu, "cpu.stat"))
+	if err != nil {
+		return cgroupCPU{}, err
+	}
+	cpu.periods = stat["nr_periods"]
+	cpu.throttledPeriods = stat["nr_throttled"]
+
+	if dirs.version == 2 {
+		cpu.usageUs = stat["usage_usec"]
+		cpu.throttledUs = stat["throttled_usec"]
+		return cpu, nil
+	}
+
+	// cgroup v1 counts in nanoseconds and keeps the usage in the cpuacct controller
+	cpu.throttledUs = stat["throttled_time"] / 1000
+	if dirs.cpuacct == "" {
+		return cgroupCPU{}, errors.New("the cpuacct cgroup controller is not mounted")
+	}
+	usageNs, err := readCgroupValue(filepath.Join(dirs.cpuacct, "cpuacct.usage"))
+	if err != nil {
+		return cgroupCPU{}, err
+	}
+	cpu.usageUs = usageNs / 1000
+	return cpu, nil
+}
+
+// readCgroupValue reads a cgroup file holding a single number
+// "max", used by cgroup v2 for no limit, is returned as the largest value.
+func readCgroupValue(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return math.MaxUint64, nil
+	}
+	number, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value: %w", path, err)
+	}
+	return number, nil
+}
+
+// readCgroupStat reads a cgroup file of "key value" lines, such as memory.stat or cpu.stat
+func readCgroupStat(path string) (map[string]uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat := make(map[string]uint64)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if value, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			stat[fields[0]] = value
+		}
+	}
+	return stat, nil
+}
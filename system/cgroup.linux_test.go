@@ -0,0 +1,191 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCgroupFiles creates a cgroup directory holding the given files
+func writeCgroupFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadCgroupMemory(t *testing.T) {
+	const mb = 1024 * 1024
+	tests := []struct {
+		name    string
+		version int
+		files   map[string]string
+		want    cgroupMemory
+		wantErr bool
+	}{
+		{
+			name:    "v2 with a limit",
+			version: 2,
+			files: map[string]string{
+				"memory.max":     "536870912\n",
+				"memory.current": "209715200\n",
+				"memory.stat":    "anon 104857600\ninactive_file 52428800\nactive_file 1048576\n",
+			},
+			want: cgroupMemory{limit: 512 * mb, used: 150 * mb},
+		},
+		{
+			name:    "v2 without a limit",
+			version: 2,
+			files: map[string]string{
+				"memory.max":     "max\n",
+				"memory.current": "209715200\n",
+				"memory.stat":    "inactive_file 0\n",
+			},
+			want: cgroupMemory{used: 200 * mb},
+		},
+		{
+			name:    "v2 root cgroup without memory.max",
+			version: 2,
+			files: map[string]string{
+				"memory.current": "209715200\n",
+				"memory.stat":    "inactive_file 9437184\n",
+			},
+			want: cgroupMemory{used: 191 * mb},
+		},
+		{
+			name:    "v2 inactive page cache above usage",
+			version: 2,
+			files: map[string]string{
+				"memory.max":     "max\n",
+				"memory.current": "1048576\n",
+				"memory.stat":    "inactive_file 2097152\n",
+			},
+			want: cgroupMemory{used: 1 * mb},
+		},
+		{
+			name:    "v1 with a limit",
+			version: 1,
+			files: map[string]string{
+				"memory.limit_in_bytes": "1073741824\n",
+				"memory.usage_in_bytes": "314572800\n",
+				"memory.stat":           "inactive_file 1\ntotal_inactive_file 104857600\n",
+			},
+			want: cgroupMemory{limit: 1024 * mb, used: 200 * mb},
+		},
+		{
+			name:    "v1 without a limit",
+			version: 1,
+			files: map[string]string{
+				"memory.limit_in_bytes": "9223372036854771712\n",
+				"memory.usage_in_bytes": "314572800\n",
+				"memory.stat":           "total_inactive_file 0\n",
+			},
+			want: cgroupMemory{used: 300 * mb},
+		},
+		{
+			name:    "v1 without memory.limit_in_bytes",
+			version: 1,
+			files: map[string]string{
+				"memory.usage_in_bytes": "314572800\n",
+				"memory.stat":           "total_inactive_file 0\n",
+			},
+			wantErr: true,
+		},
+		{
+			name:    "v2 invalid limit",
+			version: 2,
+			files: map[string]string{
+				"memory.max":     "lots\n",
+				"memory.current": "1048576\n",
+				"memory.stat":    "inactive_file 0\n",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirs := cgroupDirs{version: tt.version, memory: writeCgroupFiles(t, tt.files)}
+			got, err := readCgroupMemory(dirs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readCgroupMemory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readCgroupMemory() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCgroupCPUQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int
+		files   map[string]string
+		want    float64
+		wantErr bool
+	}{
+		{name: "v2 with a quota", version: 2, files: map[string]string{"cpu.max": "150000 100000\n"}, want: 1.5},
+		{name: "v2 without a quota", version: 2, files: map[string]string{"cpu.max": "max 100000\n"}, want: 0},
+		{name: "v2 root cgroup without cpu.max", version: 2, want: 0},
+		{name: "v2 invalid format", version: 2, files: map[string]string{"cpu.max": "150000\n"}, wantErr: true},
+		{name: "v1 with a quota", version: 1, files: map[string]string{"cpu.cfs_quota_us": "50000\n", "cpu.cfs_period_us": "100000\n"}, want: 0.5},
+		{name: "v1 without a quota", version: 1, files: map[string]string{"cpu.cfs_quota_us": "-1\n", "cpu.cfs_period_us": "100000\n"}, want: 0},
+		{name: "v1 zero period", version: 1, files: map[string]string{"cpu.cfs_quota_us": "50000\n", "cpu.cfs_period_us": "0\n"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dirs := cgroupDirs{version: tt.version, cpu: writeCgroupFiles(t, tt.files)}
+			got, err := readCgroupCPUQuota(dirs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readCgroupCPUQuota() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readCgroupCPUQuota() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCgroupMountDir(t *testing.T) {
+	tests := []struct {
+		name  string
+		mount cgroupMount
+		path  string
+		want  string
+	}{
+		{name: "host", mount: cgroupMount{root: "/", mountpoint: "/sys/fs/cgroup"}, path: "/system.slice/glance-agent.service", want: "/sys/fs/cgroup/system.slice/glance-agent.service"},
+		{name: "namespaced", mount: cgroupMount{root: "/docker/abc", mountpoint: "/sys/fs/cgroup"}, path: "/docker/abc", want: "/sys/fs/cgroup"},
+		{name: "outside the mounted part", mount: cgroupMount{root: "/docker/abc", mountpoint: "/sys/fs/cgroup/memory"}, path: "/user.slice", want: "/sys/fs/cgroup/memory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.mount.dir(tt.path); got != tt.want {
+				t.Errorf("dir(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "runtime"
+
+// getCgroupInfo is not implemented on Windows
+// cgroup data is reported as unavailable
+func getCgroupInfo() (CgroupInfo, error) {
+	return CgroupInfo{}, nil
+}
+
+// applyCgroupMemoryLimit is not implemented on Windows
+func applyCgroupMemoryLimit(_ *MemoryInfo) {}
+
+// effectiveCPUCount returns the number of CPUs load averages are divided by
+func effectiveCPUCount() float64 {
+	return float64(runtime.NumCPU())
+}
@@ -0,0 +1,84 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"context"
+	"sync"
+)
+
+// Collector gathers a single section of the system information
+// New metric sources implement this interface and register themselves with RegisterCollector,
+// usually from an init function in their own file.
+type Collector interface {
+	// Name returns the unique name of the section, e.g. "memory"
+	Name() string
+	// Enabled reports whether the section is enabled by the feature toggles
+	Enabled() bool
+	// Collect gathers the section and stores it in info
+	Collect(ctx context.Context, info *SystemInfo) error
+}
+
+// SectionCopier is implemented by collectors whose section can be served from a cached snapshot
+// Sections of collectors that do not implement it are collected on demand when requested individually.
+type SectionCopier interface {
+	// CopySection copies the collector's section from src into dst
+	CopySection(dst, src *SystemInfo)
+}
+
+// registry holds all registered collectors in registration order
+var registry = struct {
+	sync.RWMutex
+	collectors []Collector
+}{}
+
+// RegisterCollector adds a collector to the registry
+// A collector with the same name as an existing one replaces it.
+func RegisterCollector(c Collector) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for i, existing := range registry.collectors {
+		if existing.Name() == c.Name() {
+			registry.collectors[i] = c
+			return
+		}
+	}
+	registry.collectors = append(registry.collectors, c)
+}
+
+// GetCollectors returns a copy of all registered collectors
+func GetCollectors() []Collector {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	collectors := make([]Collector, len(registry.collectors))
+	copy(collectors, registry.collectors)
+	return collectors
+}
+
+// GetCollector returns the registered collector with the given name
+func GetCollector(name string) (Collector, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	for _, c := range registry.collectors {
+		if c.Name() == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
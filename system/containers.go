@@ -0,0 +1,76 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"context"
+	"strings"
+)
+
+func init() {
+	RegisterCollector(containersCollector{})
+}
+
+// defaultContainerSocket is the Unix socket of the Docker API
+// Podman serves a compatible API on its own socket, e.g. /run/podman/podman.sock.
+const defaultContainerSocket = "/var/run/docker.sock"
+
+// containersCollector gathers the containers of the Docker or Podman engine
+type containersCollector struct{}
+
+// Name returns the section name
+func (containersCollector) Name() string {
+	return "containers"
+}
+
+// Enabled reports whether container monitoring is enabled
+func (containersCollector) Enabled() bool {
+	return !features().DisableContainers
+}
+
+// Collect gathers the containers into info.Containers
+// Containers that could be read are kept even when others fail
+func (containersCollector) Collect(ctx context.Context, info *SystemInfo) error {
+	containers, err := getContainers(ctx)
+	info.Containers = containers
+	return err
+}
+
+// CopySection copies the containers from src into dst
+func (containersCollector) CopySection(dst, src *SystemInfo) {
+	dst.Containers = src.Containers
+}
+
+// shouldIgnoreContainer checks if a container is filtered out by its labels
+func shouldIgnoreContainer(labels map[string]string) bool {
+	settings := getSettings()
+	if len(settings.IncludedContainerLabels) > 0 && !matchesAnyLabel(labels, settings.IncludedContainerLabels) {
+		return true
+	}
+	return matchesAnyLabel(labels, settings.ExcludedContainerLabels)
+}
+
+// matchesAnyLabel checks if a container has any of the labels
+// A filter is either "key", matching any value, or "key=value".
+func matchesAnyLabel(labels map[string]string, filters []string) bool {
+	for _, filter := range filters {
+		key, value, hasValue := strings.Cut(filter, "=")
+		if actual, exists := labels[key]; exists && (!hasValue || actual == value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// containerSample holds the counters of a container used to calculate rates
+type containerSample struct {
+	cpuUsage  uint64    // CPU time used by the container in nanoseconds
+	systemCPU uint64    // CPU time of all cores of the host in nanoseconds
+	rxBytes   uint64    // Total bytes received
+	txBytes   uint64    // Total bytes transmitted
+	at        time.Time // When the sample was taken
+}
+
+// containerSampler keeps the previous sample of each container so rates can be calculated from deltas
+var containerSampler = struct {
+	sync.Mutex
+	previous map[string]containerSample // Keyed by container ID
+}{}
+
+// getContainers lists the containers of the engine with their state and resource usage
+// A missing socket means no engine is installed and a socket the agent may not open means
+// it was not given access, both are reported as unavailable rather than as an error.
+func getContainers(ctx context.Context) (ContainersInfo, error) {
+	client := getDockerClient(getSettings().ContainerSocket)
+	listed, err := client.listContainers(ctx)
+	if errors.Is(err, os.ErrNotExist) {
+		return ContainersInfo{}, nil
+	} else if errors.Is(err, os.ErrPermission) {
+		if !client.deniedLogged.Swap(true) {
+			log.Printf("Containers are not reported, permission denied on %s. Add the agent's user to the docker group to report them", client.socket)
+		}
+		return ContainersInfo{}, nil
+	} else if err != nil {
+		return ContainersInfo{}, fmt.Errorf("failed to list containers on %s: %w", client.socket, err)
+	}
+	listed = slices.DeleteFunc(listed, func(c apiContainer) bool { return shouldIgnoreContainer(c.Labels) })
+
+	// Containers are read concurrently, as a stats request waits on the engine
+	containers := make([]Container, len(listed))
+	samples := make([]*containerSample, len(listed))
+	containerErrors := make([]error, len(listed))
+	var wg sync.WaitGroup
+	for i, c := range listed {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			containers[i], samples[i], containerErrors[i] = readContainer(ctx, client, c)
+		}()
+	}
+	wg.Wait()
+
+	containerSampler.Lock()
+	previous := containerSampler.previous
+	current := make(map[string]containerSample, len(listed))
+	for i, sample := range samples {
+		if sample != nil {
+			current[listed[i].ID] = *sample
+			applyContainerRates(&containers[i], previous[listed[i].ID], *sample)
+		}
+	}
+	containerSampler.previous = current
+	containerSampler.Unlock()
+
+	// Containers removed while being read are left out, others are kept with what could be read
+	var errs []error
+	found := make([]Container, 0, len(containers))
+	for i, container := range containers {
+		if isNotFound(containerErrors[i]) {
+			continue
+		}
+		if containerErrors[i] != nil {
+			errs = append(errs, fmt.Errorf("container %s: %w", container.Name, containerErrors[i]))
+		}
+		found = append(found, container)
+	}
+	slices.SortFunc(found, func(a, b Container) int { return cmp.Compare(a.Name, b.Name) })
+
+	return ContainersInfo{ContainersIsAvailable: true, Containers: found}, errors.Join(errs...)
+}
+
+// readContainer reads the details of a container and, if it is running, its resource usage
+// The returned sample is nil for containers that are not running.
+func readContainer(ctx context.Context, client *dockerClient, c apiContainer) (Container, *containerSample, error) {
+	container := Container{
+		ID:    c.ID[:min(12, len(c.ID))],
+		Image: c.Image,
+		State: c.State,
+	}
+	if len(c.Names) > 0 {
+		container.Name = strings.TrimPrefix(c.Names[0], "/")
+	}
+
+	details, err := client.inspectContainer(ctx, c.ID)
+	if err != nil {
+		return container, nil, err
+	}
+	container.RestartCount = details.RestartCount
+	if details.State.Health != nil {
+		container.Health = details.State.Health.Status
+	}
+	if startedAt := details.State.StartedAt; !startedAt.IsZero() {
+		container.StartedAt = startedAt.Unix()
+		if c.State == "running" {
+			container.UptimeSeconds = int64(time.Since(startedAt).Seconds())
+		}
+	}
+
+	if c.State != "running" {
+		return container, nil, nil
+	}
+	stats, err := client.containerStats(ctx, c.ID)
+	if err != nil {
+		return container, nil, err
+	}
+
+	// Inactive page cache can be reclaimed, so it is not counted as used like in docker stats
+	memory := stats.MemoryStats
+	used := memory.Usage
+	for _, key := range []string{"inactive_file", "total_inactive_file"} { // cgroup v2 and v1
+		if inactive, exists := memory.Stats[key]; exists && inactive < used {
+			used -= inactive
+			break
+		}
+	}
+	container.StatsIsAvailable = true
+	container.MemoryUsedMB = int(used / (1024 * 1024))
+	container.MemoryLimitMB = int(memory.Limit / (1024 * 1024))
+	if memory.Limit > 0 {
+		container.MemoryUsedPercent = int(used * 100 / memory.Limit)
+	}
+
+	sample := &containerSample{
+		cpuUsage:  stats.CPUStats.CPUUsage.TotalUsage,
+		systemCPU: stats.CPUStats.SystemCPUUsage,
+		at:        time.Now(),
+	}
+	for _, network := range stats.Networks {
+		sample.rxBytes += network.RxBytes
+		sample.txBytes += network.TxBytes
+	}
+	container.NetworkRxBytes = sample.rxBytes
+	container.NetworkTxBytes = sample.txBytes
+
+	return container, sample, nil
+}
+
+// applyContainerRates sets the CPU usage and network rates of a container from two samples
+// Rates stay 0 when there is no previous sample or the counters were reset by a restart.
+func applyContainerRates(container *Container, previous, current containerSample) {
+	if previous.at.IsZero() {
+		return
+	}
+
+	if current.systemCPU > previous.systemCPU && current.cpuUsage >= previous.cpuUsage {
+		percent := float64(current.cpuUsage-previous.cpuUsage) / float64(current.systemCPU-previous.systemCPU) * 100
+		container.CPUPercent = math.Round(percent*100) / 100 // Round to 2 decimal places
+	}
+
+	elapsed := current.at.Sub(previous.at).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	if current.rxBytes >= previous.rxBytes {
+		container.NetworkRxBytesPerSec = float64(current.rxBytes-previous.rxBytes) / elapsed
+	}
+	if current.txBytes >= previous.txBytes {
+		container.NetworkTxBytesPerSec = float64(current.txBytes-previous.txBytes) / elapsed
+	}
+}
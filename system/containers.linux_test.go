@@ -0,0 +1,254 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const mb = 1024 * 1024
+
+// fakeEngine serves canned Docker API responses on a temporary unix socket
+// Each stats request of a container advances its counters, so rates can be checked.
+type fakeEngine struct {
+	mu    sync.Mutex
+	stats map[string]int // Number of stats requests per container ID
+}
+
+func (e *fakeEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reply := func(status int, body any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+
+	if r.URL.Path == "/containers/json" {
+		if r.URL.Query().Get("all") != "true" {
+			reply(http.StatusBadRequest, map[string]string{"message": "expected all=true"})
+			return
+		}
+		reply(http.StatusOK, []map[string]any{
+			{"Id": "aaaaaaaaaaaaaaaa", "Names": []string{"/web"}, "Image": "nginx:1", "State": "running", "Labels": map[string]string{"app": "web"}},
+			{"Id": "bbbbbbbbbbbbbbbb", "Names": []string{"/db"}, "Image": "postgres:16", "State": "exited", "Labels": map[string]string{"app": "db", "tier": "data"}},
+			{"Id": "cccccccccccccccc", "Names": []string{"/gone"}, "Image": "busybox", "State": "running", "Labels": map[string]string{}},
+		})
+		return
+	}
+
+	id, endpoint, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/containers/"), "/")
+	if id == "cccccccccccccccc" {
+		// Removed between the list and the inspect request
+		reply(http.StatusNotFound, map[string]string{"message": "No such container: " + id})
+		return
+	}
+
+	switch endpoint {
+	case "json":
+		details := map[string]any{"RestartCount": 2, "State": map[string]any{"StartedAt": "0001-01-01T00:00:00Z"}}
+		if id == "aaaaaaaaaaaaaaaa" {
+			details["State"] = map[string]any{"StartedAt": "2026-01-02T03:04:05.123456789Z", "Health": map[string]string{"Status": "healthy"}}
+		}
+		reply(http.StatusOK, details)
+	case "stats":
+		if r.URL.Query().Get("stream") != "false" || r.URL.Query().Get("one-shot") != "true" {
+			reply(http.StatusBadRequest, map[string]string{"message": "expected a one-shot sample"})
+			return
+		}
+		e.mu.Lock()
+		e.stats[id]++
+		n := uint64(e.stats[id])
+		e.mu.Unlock()
+		reply(http.StatusOK, map[string]any{
+			"cpu_stats": map[string]any{
+				"cpu_usage":        map[string]uint64{"total_usage": n * 250_000_000},
+				"system_cpu_usage": n * 1_000_000_000,
+			},
+			"memory_stats": map[string]any{
+				"usage": 300 * mb,
+				"limit": 1024 * mb,
+				"stats": map[string]uint64{"inactive_file": 100 * mb},
+			},
+			"networks": map[string]any{
+				"eth0": map[string]uint64{"rx_bytes": n * 1000, "tx_bytes": n * 500},
+				"eth1": map[string]uint64{"rx_bytes": 10, "tx_bytes": 0},
+			},
+		})
+	default:
+		reply(http.StatusNotFound, map[string]string{"message": "page not found"})
+	}
+}
+
+// startFakeEngine serves a fake engine on a unix socket and points the settings at it
+func startFakeEngine(t *testing.T) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen on %s: %v", socket, err)
+	}
+	server := httptest.NewUnstartedServer(&fakeEngine{stats: make(map[string]int)})
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	setTestSettings(t, func(s *Settings) {
+		s.ContainerSocket = socket
+		s.IncludedContainerLabels = nil
+		s.ExcludedContainerLabels = nil
+	})
+	containerSampler.Lock()
+	containerSampler.previous = nil
+	containerSampler.Unlock()
+	return socket
+}
+
+func TestGetContainers(t *testing.T) {
+	startFakeEngine(t)
+
+	// The first collection has no previous sample, so rates are 0
+	info, err := getContainers(context.Background())
+	if err != nil {
+		t.Fatalf("getContainers() error = %v", err)
+	}
+	if !info.ContainersIsAvailable {
+		t.Fatal("containers not available")
+	}
+	if len(info.Containers) != 2 {
+		t.Fatalf("got %d containers, want 2 without the removed one: %+v", len(info.Containers), info.Containers)
+	}
+	if web := info.Containers[1]; web.CPUPercent != 0 || web.NetworkRxBytesPerSec != 0 {
+		t.Errorf("first collection reported rates: %+v", web)
+	}
+
+	info, err = getContainers(context.Background())
+	if err != nil {
+		t.Fatalf("getContainers() error = %v", err)
+	}
+
+	db, web := info.Containers[0], info.Containers[1]
+	if db.Name != "db" || db.State != "exited" || db.StatsIsAvailable || db.StartedAt != 0 || db.RestartCount != 2 {
+		t.Errorf("db = %+v", db)
+	}
+
+	if web.ID != "aaaaaaaaaaaa" || web.Name != "web" || web.Image != "nginx:1" || web.Health != "healthy" {
+		t.Errorf("web identity = %+v", web)
+	}
+	if web.StartedAt != 1767323045 || web.UptimeSeconds <= 0 {
+		t.Errorf("web started at %d, uptime %d", web.StartedAt, web.UptimeSeconds)
+	}
+	if !web.StatsIsAvailable {
+		t.Fatal("web stats not available")
+	}
+	// 250ms of container CPU time per 1s of host CPU time across all cores
+	if web.CPUPercent != 25 {
+		t.Errorf("CPUPercent = %v, want 25", web.CPUPercent)
+	}
+	// 300 MB usage minus 100 MB inactive page cache
+	if web.MemoryUsedMB != 200 || web.MemoryLimitMB != 1024 || web.MemoryUsedPercent != 19 {
+		t.Errorf("memory = %d MB of %d MB (%d%%), want 200 MB of 1024 MB (19%%)", web.MemoryUsedMB, web.MemoryLimitMB, web.MemoryUsedPercent)
+	}
+	// Summed over both networks
+	if web.NetworkRxBytes != 2010 || web.NetworkTxBytes != 1000 {
+		t.Errorf("network rx %d tx %d, want 2010 and 1000", web.NetworkRxBytes, web.NetworkTxBytes)
+	}
+	if web.NetworkRxBytesPerSec <= 0 || web.NetworkTxBytesPerSec <= 0 {
+		t.Errorf("network rates rx %v tx %v, want positive", web.NetworkRxBytesPerSec, web.NetworkTxBytesPerSec)
+	}
+}
+
+func TestGetContainersLabelFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		included []string
+		excluded []string
+		want     []string
+	}{
+		{name: "no filters", want: []string{"db", "web"}},
+		{name: "include by key", included: []string{"app"}, want: []string{"db", "web"}},
+		{name: "include by key and value", included: []string{"app=db"}, want: []string{"db"}},
+		{name: "exclude by key", excluded: []string{"tier"}, want: []string{"web"}},
+		{name: "include and exclude", included: []string{"app"}, excluded: []string{"tier=data"}, want: []string{"web"}},
+		{name: "value must match", included: []string{"app=cache"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			startFakeEngine(t)
+			setTestSettings(t, func(s *Settings) {
+				s.IncludedContainerLabels = tt.included
+				s.ExcludedContainerLabels = tt.excluded
+			})
+
+			info, err := getContainers(context.Background())
+			if err != nil {
+				t.Fatalf("getContainers() error = %v", err)
+			}
+			names := []string{}
+			for _, c := range info.Containers {
+				names = append(names, c.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("containers = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetContainersUnavailable(t *testing.T) {
+	t.Run("missing socket", func(t *testing.T) {
+		setTestSettings(t, func(s *Settings) { s.ContainerSocket = filepath.Join(t.TempDir(), "missing.sock") })
+		info, err := getContainers(context.Background())
+		if err != nil || info.ContainersIsAvailable {
+			t.Errorf("getContainers() = %+v, %v, want unavailable without error", info, err)
+		}
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("root is not denied access to the socket")
+		}
+		socket := startFakeEngine(t)
+		if err := os.Chmod(socket, 0); err != nil {
+			t.Fatal(err)
+		}
+		info, err := getContainers(context.Background())
+		if err != nil || info.ContainersIsAvailable {
+			t.Errorf("getContainers() = %+v, %v, want unavailable without error", info, err)
+		}
+	})
+
+	t.Run("engine not listening", func(t *testing.T) {
+		socket := filepath.Join(t.TempDir(), "stale.sock")
+		if err := os.WriteFile(socket, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		setTestSettings(t, func(s *Settings) { s.ContainerSocket = socket })
+		if _, err := getContainers(context.Background()); err == nil {
+			t.Error("getContainers() error = nil, want an error for a socket nothing listens on")
+		}
+	})
+}
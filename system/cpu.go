@@ -0,0 +1,90 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "context"
+
+func init() {
+	RegisterCollector(cpuCollector{})
+}
+
+// cpuCollector gathers CPU load averages and utilisation
+type cpuCollector struct{}
+
+// Name returns the section name
+func (cpuCollector) Name() string {
+	return "cpu"
+}
+
+// Enabled reports whether either CPU load or utilisation monitoring is enabled
+func (cpuCollector) Enabled() bool {
+	return !features().DisableCPULoad || !features().DisableCPUUsage
+}
+
+// Collect gathers CPU load averages and utilisation into info.CPU
+func (cpuCollector) Collect(_ context.Context, info *SystemInfo) error {
+	if !features().DisableCPULoad {
+		// Get number of CPU cores for load percentage calculation
+		// In cgroup-aware mode a smaller CPU quota of the agent's cgroup is used instead
+		cpuCount := effectiveCPUCount()
+
+		// Get CPU load averages
+		load1, load15, err := getLoadAverage()
+		if err != nil {
+			return err
+		}
+
+		// Calculate load percentages based on CPU count
+		// Load average of 1.0 = 100% utilization on single-core system
+		load1Percent := int((load1 / cpuCount) * 100)
+		if load1Percent > 100 {
+			load1Percent = 100 // Cap at 100%
+		}
+
+		load15Percent := int((load15 / cpuCount) * 100)
+		if load15Percent > 100 {
+			load15Percent = 100 // Cap at 100%
+		}
+
+		info.CPU.LoadIsAvailable = true
+		info.CPU.Load1Percent = load1Percent
+		info.CPU.Load15Percent = load15Percent
+	}
+
+	if !features().DisableCPUUsage {
+		// Get CPU utilisation since the previous sample
+		usage, cores, err := getCPUUsage()
+		if err != nil {
+			return err
+		}
+
+		info.CPU.UsageIsAvailable = true
+		info.CPU.Usage = usage
+		info.CPU.Cores = cores
+	}
+
+	return nil
+}
+
+// CopySection copies the CPU load and utilisation from src into dst
+func (cpuCollector) CopySection(dst, src *SystemInfo) {
+	dst.CPU.LoadIsAvailable = src.CPU.LoadIsAvailable
+	dst.CPU.Load1Percent = src.CPU.Load1Percent
+	dst.CPU.Load15Percent = src.CPU.Load15Percent
+	dst.CPU.UsageIsAvailable = src.CPU.UsageIsAvailable
+	dst.CPU.Usage = src.CPU.Usage
+	dst.CPU.Cores = src.CPU.Cores
+}
@@ -18,16 +18,19 @@ package system
 // along with this program. If not, see <https://www.gnu.org/licenses/>.
 
 import (
+	"bufio"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // getLoadAverage reads system load averages from /proc/loadavg
 // Returns 1-minute and 15-minute load averages
 func getLoadAverage() (float64, float64, error) {
-	data, err := os.ReadFile("/proc/loadavg")
+	data, err := os.ReadFile(procPath("loadavg"))
 	if err != nil {
 		return 0, 0, err
 	}
@@ -53,3 +56,142 @@ func getLoadAverage() (float64, float64, error) {
 
 	return load1, load15, nil
 }
+
+// cpuTimes holds the cumulative jiffies a CPU has spent in each state, as read from /proc/stat
+type cpuTimes struct {
+	user    uint64
+	nice    uint64
+	system  uint64
+	idle    uint64
+	iowait  uint64
+	irq     uint64
+	softirq uint64
+	steal   uint64
+}
+
+// total returns the sum of all tracked CPU states
+// guest and guest_nice are already accounted for in user and nice
+func (t cpuTimes) total() uint64 {
+	return t.user + t.nice + t.system + t.idle + t.iowait + t.irq + t.softirq + t.steal
+}
+
+// cpuSampler keeps the previous /proc/stat reading so utilisation can be computed from deltas
+var cpuSampler = struct {
+	sync.Mutex
+	previous map[string]cpuTimes
+}{}
+
+// readCPUTimes parses the aggregate and per-core CPU lines from /proc/stat
+// Returns the times keyed by name along with the names in file order
+func readCPUTimes() (map[string]cpuTimes, []string, error) {
+	file, err := os.Open(procPath("stat"))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			fmt.Fprintf(os.Stderr, "error closing file: %v\n", cerr)
+		}
+	}()
+
+	times := make(map[string]cpuTimes)
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// /proc/stat format: "cpu0 4705 356 584 3699176 23060 0 277 0 0 0"
+		// Fields: name user nice system idle iowait irq softirq steal guest guest_nice
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 9 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		values := make([]uint64, 8)
+		for i := range values {
+			values[i], err = strconv.ParseUint(fields[i+1], 10, 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid /proc/stat value for %s: %w", fields[0], err)
+			}
+		}
+
+		times[fields[0]] = cpuTimes{
+			user:    values[0],
+			nice:    values[1],
+			system:  values[2],
+			idle:    values[3],
+			iowait:  values[4],
+			irq:     values[5],
+			softirq: values[6],
+			steal:   values[7],
+		}
+		names = append(names, fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if _, exists := times["cpu"]; !exists {
+		return nil, nil, fmt.Errorf("no aggregate cpu line found in /proc/stat")
+	}
+
+	return times, names, nil
+}
+
+// getCPUUsage calculates overall and per-core CPU utilisation from /proc/stat
+// Percentages cover the time since the previous call, or since boot on the first call
+func getCPUUsage() (CPUUsage, []CPUUsage, error) {
+	current, names, err := readCPUTimes()
+	if err != nil {
+		return CPUUsage{}, nil, err
+	}
+
+	cpuSampler.Lock()
+	previous := cpuSampler.previous
+	cpuSampler.previous = current
+	cpuSampler.Unlock()
+
+	var total CPUUsage
+	cores := make([]CPUUsage, 0, len(names)-1)
+	for _, name := range names {
+		usage := calculateCPUUsage(previous[name], current[name])
+		if name == "cpu" {
+			usage.Name = "total"
+			total = usage
+			continue
+		}
+		usage.Name = name
+		cores = append(cores, usage)
+	}
+
+	return total, cores, nil
+}
+
+// calculateCPUUsage converts the difference between two samples into percentages
+func calculateCPUUsage(previous, current cpuTimes) CPUUsage {
+	// Counters can go backwards when a core is hotplugged; start from zero in that case
+	if current.total() < previous.total() {
+		previous = cpuTimes{}
+	}
+
+	delta := float64(current.total() - previous.total())
+	if delta == 0 {
+		return CPUUsage{IdlePercent: 100}
+	}
+
+	percent := func(curr, prev uint64) float64 {
+		if curr < prev {
+			return 0
+		}
+		return math.Round(float64(curr-prev)/delta*10000) / 100 // Round to 2 decimal places
+	}
+
+	usage := CPUUsage{
+		UserPercent:   percent(current.user+current.nice, previous.user+previous.nice),
+		SystemPercent: percent(current.system+current.irq+current.softirq, previous.system+previous.irq+previous.softirq),
+		IOWaitPercent: percent(current.iowait, previous.iowait),
+		StealPercent:  percent(current.steal, previous.steal),
+		IdlePercent:   percent(current.idle, previous.idle),
+	}
+	usage.UsedPercent = math.Round((usage.UserPercent+usage.SystemPercent+usage.StealPercent)*100) / 100
+
+	return usage
+}
@@ -136,3 +136,18 @@ func getCPUUsagePercentage() (float64, error) {
 
 	return 0, fmt.Errorf("could not parse CPU usage from wmic output")
 }
+
+// getCPUUsage returns the overall CPU utilisation reported by wmic
+// Windows does not expose a per-state breakdown or per-core values this way
+func getCPUUsage() (CPUUsage, []CPUUsage, error) {
+	cpuUsage, err := getCPUUsagePercentage()
+	if err != nil {
+		return CPUUsage{}, nil, err
+	}
+
+	return CPUUsage{
+		Name:        "total",
+		UsedPercent: cpuUsage,
+		IdlePercent: 100 - cpuUsage,
+	}, []CPUUsage{}, nil
+}
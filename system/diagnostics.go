@@ -0,0 +1,33 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// ThermalDiagnostics describes the thermal zones and the one used for the CPU temperature
+type ThermalDiagnostics struct {
+	Zones      []ThermalZone // Every readable thermal zone
+	Configured int           // Configured zone, -1 when the zone is autodetected
+	Selected   string        // Name of the zone used for the CPU temperature, empty if none
+	Reason     string        // Why the zone was selected
+}
+
+// MountDiagnostics describes a mount entry and whether it is reported
+type MountDiagnostics struct {
+	Device     string // Mounted device or filesystem source
+	Mountpoint string // Mount path
+	FSType     string // Filesystem type
+	Included   bool   // Whether the mount is reported
+	Reason     string // Rule that ignored the mount, or how it is measured when included
+}
@@ -0,0 +1,122 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// DiagnoseThermalZones lists the thermal zones and explains which one is used for the CPU temperature
+// Unlike the collector it does not cache the autodetected zone or log skipped zones.
+func DiagnoseThermalZones() (ThermalDiagnostics, error) {
+	diagnostics := ThermalDiagnostics{Configured: getSettings().CPUThermalZone}
+
+	zones, err := readThermalZones(false)
+	if err != nil {
+		return diagnostics, err
+	}
+	diagnostics.Zones = zones
+
+	if diagnostics.Configured >= 0 {
+		diagnostics.Selected = fmt.Sprintf("thermal_zone%d", diagnostics.Configured)
+		diagnostics.Reason = "configured by THERMAL_ZONE"
+		if _, err := os.Stat(sysPath("class", "thermal", fmt.Sprintf("thermal_zone%d", diagnostics.Configured), "temp")); err != nil {
+			diagnostics.Reason += ", but the zone cannot be read"
+		}
+		return diagnostics, nil
+	}
+
+	if zone, err := selectPrimaryCPUThermalZone(zones); err == nil {
+		diagnostics.Selected = zone.Name
+		diagnostics.Reason = fmt.Sprintf("autodetected from the preferred sensor type %s", zone.Type)
+		return diagnostics, nil
+	}
+
+	// The collector falls back to the first zone when no preferred sensor type is found
+	diagnostics.Selected = "thermal_zone0"
+	diagnostics.Reason = "no preferred CPU sensor type found, falling back to the first zone"
+	if _, err := os.Stat(sysPath("class", "thermal", "thermal_zone0", "temp")); err != nil {
+		diagnostics.Selected = ""
+		diagnostics.Reason = "no preferred CPU sensor type found and thermal_zone0 cannot be read, temperature is unavailable"
+	}
+	return diagnostics, nil
+}
+
+// DiagnoseMounts lists every entry of /proc/mounts and whether it is reported
+func DiagnoseMounts() ([]MountDiagnostics, error) {
+	file, err := os.Open(procProcessPath("mounts"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			fmt.Fprintf(os.Stderr, "error closing file: %v\n", cerr)
+		}
+	}()
+
+	diskDisabled := features().DisableDisk
+	var mounts []MountDiagnostics
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue // Skip malformed lines
+		}
+
+		mount := MountDiagnostics{
+			Device:     fields[0],
+			Mountpoint: fields[1],
+			FSType:     fields[2],
+		}
+		rule := mountpointIgnoreRule(mount.Mountpoint, mount.FSType)
+		switch {
+		case diskDisabled:
+			mount.Reason = "disk monitoring is disabled"
+		case rule != "":
+			mount.Reason = rule
+		case mount.FSType == "zfs":
+			if _, err := exec.LookPath("zfs"); err != nil {
+				mount.Reason = "zfs command not found on PATH"
+			} else {
+				mount.Included = true
+				mount.Reason = "usage read with the zfs command"
+			}
+		default:
+			mount.Included, mount.Reason = diagnoseUsedSpace(mount.Mountpoint)
+		}
+		mounts = append(mounts, mount)
+	}
+
+	return mounts, scanner.Err()
+}
+
+// diagnoseUsedSpace reports whether a mountpoint has usage the collector can report
+func diagnoseUsedSpace(mountpoint string) (bool, string) {
+	usage, err := getUsedSpace(mountpoint)
+	if err != nil {
+		return false, err.Error()
+	}
+	if usage.totalMB <= 0 {
+		return false, "no storage capacity"
+	}
+	return true, fmt.Sprintf("%d MB", usage.totalMB)
+}
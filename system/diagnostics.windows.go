@@ -0,0 +1,30 @@
+//go:build windows
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "errors"
+
+// DiagnoseThermalZones is not available on Windows, the temperature is read from WMI
+func DiagnoseThermalZones() (ThermalDiagnostics, error) {
+	return ThermalDiagnostics{Configured: -1}, errors.New("thermal zones are only available on Linux, the temperature is read from WMI")
+}
+
+// DiagnoseMounts is not available on Windows, drives are listed with wmic
+func DiagnoseMounts() ([]MountDiagnostics, error) {
+	return nil, errors.New("mount diagnostics are only available on Linux")
+}
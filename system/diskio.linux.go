@@ -0,0 +1,190 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sectorSize is the size in bytes of the sectors counted in /proc/diskstats
+// The kernel always reports 512 byte sectors, regardless of the device's physical sector size
+const sectorSize = 512
+
+// ignoredBlockDevices defines block device name prefixes to ignore
+var ignoredBlockDevices = []string{
+	"loop",
+	"ram",
+	"zram",
+	"fd",
+	"sr",
+}
+
+// blockDeviceCounters holds the counters of a block device at a point in time
+type blockDeviceCounters struct {
+	readsCompleted  uint64
+	writesCompleted uint64
+	sectorsRead     uint64
+	sectorsWritten  uint64
+	ioTimeMs        uint64
+	sampledAt       time.Time
+}
+
+// diskIOSampler keeps the previous counters per device so rates can be computed from deltas
+var diskIOSampler = struct {
+	sync.Mutex
+	previous map[string]blockDeviceCounters
+}{}
+
+// getBlockDeviceIO reads block device I/O statistics from /proc/diskstats
+// Devices that have never completed a read or write are skipped
+func getBlockDeviceIO() ([]DiskIO, error) {
+	file, err := os.Open(procPath("diskstats"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			fmt.Fprintf(os.Stderr, "error closing file: %v\n", cerr)
+		}
+	}()
+
+	now := time.Now()
+	current := make(map[string]blockDeviceCounters)
+	devices := []DiskIO{}
+
+	diskIOSampler.Lock()
+	defer diskIOSampler.Unlock()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// /proc/diskstats format:
+		// "   8       0 sda 1234 56 78901 234 5678 90 12345 678 0 901 234 ..."
+		// Fields: major minor name reads merged sectors_read ms_reading
+		//         writes merged sectors_written ms_writing in_progress ms_io weighted_ms_io ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 14 {
+			continue // Skip malformed lines
+		}
+
+		name := fields[2]
+		if shouldIgnoreBlockDevice(name) {
+			continue
+		}
+
+		values := make([]uint64, 11)
+		for i := range values {
+			values[i], err = strconv.ParseUint(fields[i+3], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid /proc/diskstats value for %s: %w", name, err)
+			}
+		}
+
+		sample := blockDeviceCounters{
+			readsCompleted:  values[0],
+			sectorsRead:     values[2],
+			writesCompleted: values[4],
+			sectorsWritten:  values[6],
+			ioTimeMs:        values[9],
+			sampledAt:       now,
+		}
+		if sample.readsCompleted == 0 && sample.writesCompleted == 0 {
+			continue // Skip devices that have never been used
+		}
+		current[name] = sample
+
+		device := DiskIO{
+			Device:          name,
+			ReadBytes:       sample.sectorsRead * sectorSize,
+			WriteBytes:      sample.sectorsWritten * sectorSize,
+			ReadsCompleted:  sample.readsCompleted,
+			WritesCompleted: sample.writesCompleted,
+			IOTimeMs:        sample.ioTimeMs,
+			deviceNumber:    fields[0] + ":" + fields[1],
+		}
+
+		// Calculate rates against the previous sample, if there is one
+		if previous, exists := diskIOSampler.previous[name]; exists {
+			elapsed := now.Sub(previous.sampledAt).Seconds()
+			device.ReadBytesPerSec = ratePerSecond(previous.sectorsRead*sectorSize, sample.sectorsRead*sectorSize, elapsed)
+			device.WriteBytesPerSec = ratePerSecond(previous.sectorsWritten*sectorSize, sample.sectorsWritten*sectorSize, elapsed)
+			device.ReadIOPS = ratePerSecond(previous.readsCompleted, sample.readsCompleted, elapsed)
+			device.WriteIOPS = ratePerSecond(previous.writesCompleted, sample.writesCompleted, elapsed)
+			// Busy milliseconds per second of wall time, as a percentage
+			device.UtilPercent = math.Min(ratePerSecond(previous.ioTimeMs, sample.ioTimeMs, elapsed)/10, 100)
+		}
+
+		devices = append(devices, device)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	diskIOSampler.previous = current
+
+	return devices, nil
+}
+
+// shouldIgnoreBlockDevice checks if a block device should be ignored
+func shouldIgnoreBlockDevice(name string) bool {
+	for _, ignored := range ignoredBlockDevices {
+		if strings.HasPrefix(name, ignored) {
+			return true
+		}
+	}
+	return false
+}
+
+// getMountDeviceNumbers maps each mountpoint to the major:minor number of its device
+// using /proc/self/mountinfo
+func getMountDeviceNumbers() (map[string]string, error) {
+	file, err := os.Open(procProcessPath("mountinfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			fmt.Fprintf(os.Stderr, "error closing file: %v\n", cerr)
+		}
+	}()
+
+	devices := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// /proc/self/mountinfo format:
+		// "36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue"
+		// Fields: id parent major:minor root mountpoint options ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue // Skip malformed lines
+		}
+		// Mountpoints are escaped the same way as in /proc/mounts, so they match MountPoint.Path
+		devices[fields[4]] = fields[2]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
@@ -0,0 +1,179 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testDiskstats = `   7       0 loop0 52 0 2096 12 0 0 0 0 0 16 12 0 0 0 0 0 0
+   8       0 sda 2000 10 40000 900 1000 20 16000 700 0 1500 1600 0 0 0 0 0 0
+   8       1 sda1 1800 10 36000 800 900 20 14000 600 0 1400 1400 0 0 0 0 0 0
+   8       2 sda2 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+ 259       0 nvme0n1 500 0 8000 100 250 0 4000 50 0 200 150
+  11       0 sr0 10 0 80 1 0 0 0 0 0 1 1
+   8      16 sdb 1 2 3
+`
+
+const testMountinfo = `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+23 22 0:21 / /proc rw,nosuid,nodev,noexec,relatime shared:2 - proc proc rw
+24 22 259:0 / /mnt/fast\040disk rw,relatime shared:3 - xfs /dev/nvme0n1 rw
+25 22 0:44 / /mnt/share rw,relatime shared:4 - nfs4 server:/share rw
+malformed
+`
+
+// writeTestProc creates a host /proc with diskstats and the mountinfo of the host's init process
+func writeTestProc(t *testing.T, diskstats, mountinfo string) {
+	t.Helper()
+	proc := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(proc, "1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(proc, "diskstats"), []byte(diskstats), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(proc, "1", "mountinfo"), []byte(mountinfo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	setTestSettings(t, func(s *Settings) {
+		s.HostPaths.Proc = proc
+	})
+
+	diskIOSampler.Lock()
+	diskIOSampler.previous = nil
+	diskIOSampler.Unlock()
+}
+
+func TestGetBlockDeviceIO(t *testing.T) {
+	writeTestProc(t, testDiskstats, testMountinfo)
+
+	devices, err := getBlockDeviceIO()
+	if err != nil {
+		t.Fatalf("getBlockDeviceIO() error = %v", err)
+	}
+
+	// loop and optical devices, unused partitions and malformed lines are skipped
+	want := []DiskIO{
+		{Device: "sda", ReadBytes: 40000 * 512, WriteBytes: 16000 * 512, ReadsCompleted: 2000, WritesCompleted: 1000, IOTimeMs: 1500, deviceNumber: "8:0"},
+		{Device: "sda1", ReadBytes: 36000 * 512, WriteBytes: 14000 * 512, ReadsCompleted: 1800, WritesCompleted: 900, IOTimeMs: 1400, deviceNumber: "8:1"},
+		{Device: "nvme0n1", ReadBytes: 8000 * 512, WriteBytes: 4000 * 512, ReadsCompleted: 500, WritesCompleted: 250, IOTimeMs: 200, deviceNumber: "259:0"},
+	}
+	if len(devices) != len(want) {
+		t.Fatalf("got %d devices, want %d: %+v", len(devices), len(want), devices)
+	}
+	for i := range want {
+		if devices[i] != want[i] {
+			t.Errorf("device %d = %+v, want %+v", i, devices[i], want[i])
+		}
+	}
+}
+
+func TestGetBlockDeviceIORates(t *testing.T) {
+	writeTestProc(t, testDiskstats, testMountinfo)
+
+	// Counters of sda 10 seconds earlier
+	diskIOSampler.Lock()
+	diskIOSampler.previous = map[string]blockDeviceCounters{
+		"sda": {readsCompleted: 1000, writesCompleted: 500, sectorsRead: 20000, sectorsWritten: 6000, ioTimeMs: 1000, sampledAt: time.Now().Add(-10 * time.Second)},
+	}
+	diskIOSampler.Unlock()
+
+	devices, err := getBlockDeviceIO()
+	if err != nil {
+		t.Fatalf("getBlockDeviceIO() error = %v", err)
+	}
+
+	sda, sda1 := devices[0], devices[1]
+	near := func(got, want float64) bool { return math.Abs(got-want) <= want*0.01 }
+	if !near(sda.ReadBytesPerSec, 20000*512/10) || !near(sda.WriteBytesPerSec, 10000*512/10) {
+		t.Errorf("sda throughput read %v write %v, want %v and %v", sda.ReadBytesPerSec, sda.WriteBytesPerSec, 20000*512/10, 10000*512/10)
+	}
+	if !near(sda.ReadIOPS, 100) || !near(sda.WriteIOPS, 50) {
+		t.Errorf("sda IOPS read %v write %v, want 100 and 50", sda.ReadIOPS, sda.WriteIOPS)
+	}
+	// 500ms busy in 10 seconds
+	if !near(sda.UtilPercent, 5) {
+		t.Errorf("sda utilisation %v, want 5", sda.UtilPercent)
+	}
+	if sda1.ReadBytesPerSec != 0 || sda1.UtilPercent != 0 {
+		t.Errorf("sda1 without a previous sample reported rates: %+v", sda1)
+	}
+}
+
+func TestGetBlockDeviceIOInvalid(t *testing.T) {
+	writeTestProc(t, "   8       0 sda 2000 10 many 900 1000 20 16000 700 0 1500 1600\n", testMountinfo)
+
+	if _, err := getBlockDeviceIO(); err == nil {
+		t.Error("getBlockDeviceIO() error = nil, want an error for an invalid counter")
+	}
+}
+
+func TestGetMountDeviceNumbers(t *testing.T) {
+	writeTestProc(t, testDiskstats, testMountinfo)
+
+	devices, err := getMountDeviceNumbers()
+	if err != nil {
+		t.Fatalf("getMountDeviceNumbers() error = %v", err)
+	}
+	want := map[string]string{
+		"/":                 "8:1",
+		"/proc":             "0:21",
+		`/mnt/fast\040disk`: "259:0",
+		"/mnt/share":        "0:44",
+	}
+	if len(devices) != len(want) {
+		t.Errorf("got %d mountpoints, want %d: %v", len(devices), len(want), devices)
+	}
+	for path, number := range want {
+		if devices[path] != number {
+			t.Errorf("device of %s = %q, want %q", path, devices[path], number)
+		}
+	}
+}
+
+func TestDiskIOCollector(t *testing.T) {
+	writeTestProc(t, testDiskstats, testMountinfo)
+
+	info := newSystemInfo(0)
+	info.MountPoints = []MountPoint{{Path: "/"}, {Path: `/mnt/fast\040disk`}, {Path: "/mnt/share"}}
+	if err := (diskIOCollector{}).Collect(context.Background(), info); err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	if len(info.BlockDevices) != 3 {
+		t.Errorf("got %d block devices, want 3", len(info.BlockDevices))
+	}
+	wantDevices := []string{"sda1", "nvme0n1", ""}
+	for i, mp := range info.MountPoints {
+		if mp.IOIsAvailable != (wantDevices[i] != "") || mp.IO.Device != wantDevices[i] {
+			t.Errorf("I/O of %s = %v %q, want %q", mp.Path, mp.IOIsAvailable, mp.IO.Device, wantDevices[i])
+		}
+	}
+
+	// The section is served from a snapshot on its own
+	dst := newSystemInfo(0)
+	(diskIOCollector{}).CopySection(dst, info)
+	if len(dst.BlockDevices) != 3 || len(dst.MountPoints) != 0 {
+		t.Errorf("CopySection() copied %d block devices and %d mountpoints, want 3 and 0", len(dst.BlockDevices), len(dst.MountPoints))
+	}
+}
@@ -0,0 +1,29 @@
+//go:build windows
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// getBlockDeviceIO is not implemented on Windows
+// Disk I/O statistics are reported as unavailable
+func getBlockDeviceIO() ([]DiskIO, error) {
+	return []DiskIO{}, nil
+}
+
+// getMountDeviceNumbers is not implemented on Windows
+func getMountDeviceNumbers() (map[string]string, error) {
+	return map[string]string{}, nil
+}
@@ -15,24 +15,141 @@ package system
 // You should have received a copy of the GNU General Public License
 // along with this program. If not, see <https://www.gnu.org/licenses/>.
 
-// extraIgnoredMountpoints stores additional mountpoints to ignore
-var extraIgnoredMountpoints []string
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterCollector(diskCollector{})
+	// Registered after the disk collector, so the I/O of each device can be attached to its mountpoints
+	RegisterCollector(diskIOCollector{})
+}
+
+// diskCollector gathers filesystem usage for all mountpoints that are not ignored
+type diskCollector struct{}
+
+// Name returns the section name
+func (diskCollector) Name() string {
+	return "disk"
+}
+
+// Enabled reports whether disk monitoring is enabled
+func (diskCollector) Enabled() bool {
+	return !features().DisableDisk
+}
+
+// Collect gathers mountpoint usage into info.MountPoints
+// Mountpoints that could be read are kept even when others fail
+func (diskCollector) Collect(ctx context.Context, info *SystemInfo) error {
+	mountPoints, err := getMountPoints(ctx)
+	if mountPoints != nil {
+		info.MountPoints = mountPoints
+	}
+	return err
+}
+
+// CopySection copies mountpoint usage from src into dst
+func (diskCollector) CopySection(dst, src *SystemInfo) {
+	dst.MountPoints = src.MountPoints
+}
+
+// diskIOCollector gathers block device I/O statistics
+type diskIOCollector struct{}
+
+// Name returns the section name
+func (diskIOCollector) Name() string {
+	return "diskio"
+}
+
+// Enabled reports whether disk I/O monitoring is enabled
+func (diskIOCollector) Enabled() bool {
+	return !features().DisableDiskIO
+}
+
+// Collect gathers block device I/O into info.BlockDevices and attaches it to the mountpoints
+// collected before it, so mountpoints only report I/O when the disk collector ran as well
+func (diskIOCollector) Collect(_ context.Context, info *SystemInfo) error {
+	devices, err := getBlockDeviceIO()
+	if err != nil {
+		return err
+	}
+	info.BlockDevices = devices
+	if len(info.MountPoints) == 0 {
+		return nil
+	}
+
+	mountDevices, err := getMountDeviceNumbers()
+	if err != nil {
+		return err
+	}
+
+	byNumber := make(map[string]DiskIO, len(devices))
+	for _, device := range devices {
+		byNumber[device.deviceNumber] = device
+	}
+
+	for i, mp := range info.MountPoints {
+		if device, exists := byNumber[mountDevices[mp.Path]]; exists {
+			info.MountPoints[i].IOIsAvailable = true
+			info.MountPoints[i].IO = device
+		}
+	}
+
+	return nil
+}
+
+// CopySection copies block device I/O from src into dst
+func (diskIOCollector) CopySection(dst, src *SystemInfo) {
+	dst.BlockDevices = src.BlockDevices
+}
+
+// zfsUsage holds cached ZFS usage for a single mountpoint
+type zfsUsage struct {
+	totalMB     int
+	usedMB      int
+	usedPercent int
+	fetchedAt   time.Time
+}
+
+// zfsCache stores the last ZFS usage reading per mountpoint
+var zfsCache = struct {
+	sync.Mutex
+	usage map[string]zfsUsage
+}{usage: make(map[string]zfsUsage)}
+
+// SetZFSRefreshInterval sets how often ZFS usage is refreshed (Linux only)
+// ZFS usage requires running the zfs command, so it is refreshed less often than other metrics
+func SetZFSRefreshInterval(interval time.Duration) {
+	if interval < 0 {
+		return // Invalid interval, do nothing
+	}
+	updateSettings(func(s *Settings) {
+		s.ZFSRefreshInterval = interval
+	})
+}
 
 // AddIgnoredMountpoints adds additional mountpoints to the ignore list
 func AddIgnoredMountpoints(mountpoints []string) {
-	extraIgnoredMountpoints = append(extraIgnoredMountpoints, mountpoints...)
+	updateSettings(func(s *Settings) {
+		s.IgnoredMountpoints = append(slices.Clone(s.IgnoredMountpoints), mountpoints...)
+	})
 }
 
 // SetExtraIgnoredMountpoints replaces the extra ignored mountpoints list
 func SetExtraIgnoredMountpoints(mountpoints []string) {
-	extraIgnoredMountpoints = make([]string, len(mountpoints))
-	copy(extraIgnoredMountpoints, mountpoints)
+	updateSettings(func(s *Settings) {
+		s.IgnoredMountpoints = slices.Clone(mountpoints)
+	})
 }
 
 // GetIgnoredMountpoints returns all ignored mountpoints (default + extra)
 func GetIgnoredMountpoints() []string {
-	all := make([]string, 0, len(ignoredMountpoints)+len(extraIgnoredMountpoints))
+	extra := getSettings().IgnoredMountpoints
+	all := make([]string, 0, len(ignoredMountpoints)+len(extra))
 	all = append(all, ignoredMountpoints...)
-	all = append(all, extraIgnoredMountpoints...)
+	all = append(all, extra...)
 	return all
 }
@@ -19,13 +19,15 @@ package system
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 )
 
 // ignoredMountpoints defines default filesystem mount points to ignore
@@ -66,30 +68,40 @@ var ignoredFilesystems = []string{
 
 // shouldIgnoreMountpoint checks if a mountpoint or filesystem type should be ignored
 func shouldIgnoreMountpoint(mountpoint, fstype string) bool {
+	return mountpointIgnoreRule(mountpoint, fstype) != ""
+}
+
+// mountpointIgnoreRule returns the rule that ignores a mountpoint, or an empty string if it is reported
+func mountpointIgnoreRule(mountpoint, fstype string) string {
 	// Check if mountpoint starts with any ignored path
-	for _, ignored := range GetIgnoredMountpoints() {
+	for _, ignored := range ignoredMountpoints {
+		if strings.HasPrefix(mountpoint, ignored) {
+			return fmt.Sprintf("default ignored mountpoint %s", ignored)
+		}
+	}
+	for _, ignored := range getSettings().IgnoredMountpoints {
 		if strings.HasPrefix(mountpoint, ignored) {
-			return true
+			return fmt.Sprintf("configured ignored mountpoint %s", ignored)
 		}
 	}
 
 	// Check if filesystem type is in ignored list
 	for _, ignored := range ignoredFilesystems {
 		if fstype == ignored {
-			return true
+			return fmt.Sprintf("ignored filesystem type %s", ignored)
 		}
 	}
 
-	return false
+	return ""
 }
 
 // getMountPoints reads filesystem mount information and calculates disk usage
-func getMountPoints() ([]MountPoint, error) {
-	if disabledFeatures.DisableDisk {
+func getMountPoints(ctx context.Context) ([]MountPoint, error) {
+	if features().DisableDisk {
 		return []MountPoint{}, nil // Skip if disk monitoring is disabled
 	}
 
-	file, err := os.Open("/proc/mounts")
+	file, err := os.Open(procProcessPath("mounts"))
 	if err != nil {
 		return nil, err
 	}
@@ -100,6 +112,7 @@ func getMountPoints() ([]MountPoint, error) {
 	}()
 
 	var mountPoints []MountPoint
+	var mountErrors []error
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		fields := strings.Fields(scanner.Text())
@@ -116,18 +129,25 @@ func getMountPoints() ([]MountPoint, error) {
 		}
 
 		var totalMB, usedMB, usedPercent int
+		var inodesTotal, inodesUsed uint64
+		var inodesUsedPercent int
 		var err error
 
 		// Handle ZFS filesystems specially
+		// ZFS allocates inodes dynamically, so there is no fixed inode limit to report
 		if fstype == "zfs" {
-			totalMB, usedMB, usedPercent, err = getZFSUsage(mountpoint)
+			totalMB, usedMB, usedPercent, err = getZFSUsage(ctx, mountpoint)
 		} else {
-			totalMB, usedMB, usedPercent, err = getUsedSpace(mountpoint)
+			var usage filesystemUsage
+			usage, err = getUsedSpace(mountpoint)
+			totalMB, usedMB, usedPercent = usage.totalMB, usage.usedMB, usage.usedPercent
+			inodesTotal, inodesUsed, inodesUsedPercent = usage.inodesTotal, usage.inodesUsed, usage.inodesUsedPercent
 		}
 
 		if err != nil {
-			log.Println("Error getting used space for ", mountpoint, ": ", err)
-			continue // Skip mountpoints with errors
+			// Skip mountpoints with errors but report them alongside the others
+			mountErrors = append(mountErrors, err)
+			continue
 		}
 
 		// Only include filesystems with actual storage capacity
@@ -138,20 +158,36 @@ func getMountPoints() ([]MountPoint, error) {
 				TotalMB:     totalMB,
 				UsedMB:      usedMB,
 				UsedPercent: usedPercent,
+
+				// Some filesystems (e.g. btrfs) do not report inode counts
+				InodesIsAvailable: inodesTotal > 0,
+				InodesTotal:       inodesTotal,
+				InodesUsed:        inodesUsed,
+				InodesUsedPercent: inodesUsedPercent,
 			}
 			mountPoints = append(mountPoints, mountPoint)
 		}
 	}
 
-	return mountPoints, nil
+	return mountPoints, errors.Join(mountErrors...)
 }
 
-// getUsedSpace calculates disk usage for a given mountpoint
-func getUsedSpace(mountpoint string) (int, int, int, error) {
+// filesystemUsage holds the space and inode usage of a filesystem
+type filesystemUsage struct {
+	totalMB           int
+	usedMB            int
+	usedPercent       int
+	inodesTotal       uint64
+	inodesUsed        uint64
+	inodesUsedPercent int
+}
+
+// getUsedSpace calculates disk and inode usage for a given mountpoint
+func getUsedSpace(mountpoint string) (filesystemUsage, error) {
 	// Get filesystem statistics using syscall
 	var stat syscall.Statfs_t
-	if err := syscall.Statfs(mountpoint, &stat); err != nil {
-		return 0, 0, 0, fmt.Errorf("failed to get filesystem stats for %s: %w", mountpoint, err)
+	if err := syscall.Statfs(rootPath(mountpoint), &stat); err != nil {
+		return filesystemUsage{}, fmt.Errorf("failed to get filesystem stats for %s: %w", mountpoint, err)
 	}
 
 	// Use fragment size if available, otherwise fall back to block size
@@ -173,13 +209,55 @@ func getUsedSpace(mountpoint string) (int, int, int, error) {
 		usedPercent = int((used * 100) / total)
 	}
 
+	// Inode counts come from the same call, some filesystems (e.g. btrfs) report 0
+	inodesTotal := uint64(stat.Files)
+	inodesFree := min(uint64(stat.Ffree), inodesTotal)
+	inodesUsed := inodesTotal - inodesFree
+	inodesUsedPercent := 0
+	if inodesTotal > 0 {
+		inodesUsedPercent = int((inodesUsed * 100) / inodesTotal)
+	}
+
+	return filesystemUsage{
+		totalMB:           totalMB,
+		usedMB:            usedMB,
+		usedPercent:       usedPercent,
+		inodesTotal:       inodesTotal,
+		inodesUsed:        inodesUsed,
+		inodesUsedPercent: inodesUsedPercent,
+	}, nil
+}
+
+// getZFSUsage returns cached ZFS usage for a mountpoint, refreshing it once it is older than zfsRefreshInterval
+func getZFSUsage(ctx context.Context, mountpoint string) (int, int, int, error) {
+	zfsCache.Lock()
+	cached, exists := zfsCache.usage[mountpoint]
+	zfsCache.Unlock()
+	if exists && time.Since(cached.fetchedAt) < getSettings().ZFSRefreshInterval {
+		return cached.totalMB, cached.usedMB, cached.usedPercent, nil
+	}
+
+	totalMB, usedMB, usedPercent, err := queryZFSUsage(ctx, mountpoint)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	zfsCache.Lock()
+	zfsCache.usage[mountpoint] = zfsUsage{
+		totalMB:     totalMB,
+		usedMB:      usedMB,
+		usedPercent: usedPercent,
+		fetchedAt:   time.Now(),
+	}
+	zfsCache.Unlock()
+
 	return totalMB, usedMB, usedPercent, nil
 }
 
-// getZFSUsage gets storage usage for ZFS datasets using the zfs command
-func getZFSUsage(mountpoint string) (int, int, int, error) {
+// queryZFSUsage gets storage usage for ZFS datasets using the zfs command
+func queryZFSUsage(ctx context.Context, mountpoint string) (int, int, int, error) {
 	// Get the ZFS dataset name from mountpoint
-	cmd := exec.Command("zfs", "list", "-H", "-o", "name", mountpoint)
+	cmd := exec.CommandContext(ctx, "zfs", "list", "-H", "-o", "name", mountpoint)
 	output, err := cmd.Output()
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("failed to get ZFS dataset for %s: %w", mountpoint, err)
@@ -191,7 +269,7 @@ func getZFSUsage(mountpoint string) (int, int, int, error) {
 	}
 
 	// Get used and available space in bytes
-	cmd = exec.Command("zfs", "get", "-Hp", "-o", "value", "used,available", dataset)
+	cmd = exec.CommandContext(ctx, "zfs", "get", "-Hp", "-o", "value", "used,available", dataset)
 	output, err = cmd.Output()
 	if err != nil {
 		return 0, 0, 0, fmt.Errorf("failed to get ZFS usage for %s: %w", dataset, err)
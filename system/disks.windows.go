@@ -19,6 +19,7 @@ package system
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os/exec"
 	"strconv"
@@ -43,13 +44,13 @@ func shouldIgnoreMountpoint(mountpoint string) bool {
 
 // getMountPoints gathers disk usage info using `wmic logicaldisk`
 // and returns a parsed list of MountPoint structs representing each drive
-func getMountPoints() ([]MountPoint, error) {
-	if disabledFeatures.DisableDisk {
+func getMountPoints(ctx context.Context) ([]MountPoint, error) {
+	if features().DisableDisk {
 		return []MountPoint{}, nil // Skip if disk monitoring is disabled
 	}
 
 	// Use WMIC to query all logical disks with their drive letter, free space, and total size
-	cmd := exec.Command("wmic", "logicaldisk", "get", "Caption,Size,FreeSpace")
+	cmd := exec.CommandContext(ctx, "wmic", "logicaldisk", "get", "Caption,Size,FreeSpace")
 	var out bytes.Buffer
 	cmd.Stdout = &out
 
@@ -0,0 +1,177 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// containerAPITimeout limits each request to the container engine
+const containerAPITimeout = 5 * time.Second
+
+// dockerClient talks to the Docker Engine API, or the compatible API of Podman, over a Unix socket
+type dockerClient struct {
+	socket       string
+	http         *http.Client
+	deniedLogged atomic.Bool // Whether access to the socket was reported as denied
+}
+
+// containerEngine holds the client of the configured socket, so connections are reused between collections
+var containerEngine = struct {
+	sync.Mutex
+	client *dockerClient
+}{}
+
+// getDockerClient returns the client for a socket, replacing the previous one when the socket changed
+func getDockerClient(socket string) *dockerClient {
+	containerEngine.Lock()
+	defer containerEngine.Unlock()
+
+	if containerEngine.client != nil && containerEngine.client.socket == socket {
+		return containerEngine.client
+	}
+	if containerEngine.client != nil {
+		containerEngine.client.http.CloseIdleConnections()
+	}
+	containerEngine.client = &dockerClient{
+		socket: socket,
+		http: &http.Client{
+			Timeout: containerAPITimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+	return containerEngine.client
+}
+
+// dockerAPIError is an error response of the container engine
+type dockerAPIError struct {
+	StatusCode int
+	Message    string
+}
+
+// Error returns the message of the engine along with the status code
+func (e *dockerAPIError) Error() string {
+	return fmt.Sprintf("container engine returned %d: %s", e.StatusCode, e.Message)
+}
+
+// isNotFound reports whether the engine did not find the requested object
+func isNotFound(err error) bool {
+	var apiError *dockerAPIError
+	return errors.As(err, &apiError) && apiError.StatusCode == http.StatusNotFound
+}
+
+// get requests a path of the API and decodes the JSON response into v
+// Paths are not versioned, so the engine answers with its default API version.
+func (c *dockerClient) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		apiError := &dockerAPIError{StatusCode: resp.StatusCode, Message: resp.Status}
+		var body struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&body) == nil && body.Message != "" {
+			apiError.Message = body.Message
+		}
+		return apiError
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// apiContainer is an entry of GET /containers/json
+type apiContainer struct {
+	ID     string            `json:"Id"`
+	Names  []string          `json:"Names"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// apiContainerDetails is the part of GET /containers/{id}/json used by the agent
+type apiContainerDetails struct {
+	RestartCount int `json:"RestartCount"`
+	State        struct {
+		StartedAt time.Time `json:"StartedAt"` // Zero time if the container never started
+		Health    *struct {
+			Status string `json:"Status"`
+		} `json:"Health"` // Missing without a health check
+	} `json:"State"`
+}
+
+// apiContainerStats is the part of GET /containers/{id}/stats used by the agent
+type apiContainerStats struct {
+	CPUStats struct {
+		CPUUsage struct {
+			TotalUsage uint64 `json:"total_usage"` // CPU time used by the container in nanoseconds
+		} `json:"cpu_usage"`
+		SystemCPUUsage uint64 `json:"system_cpu_usage"` // CPU time of all cores of the host in nanoseconds
+	} `json:"cpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+	Networks map[string]struct {
+		RxBytes uint64 `json:"rx_bytes"`
+		TxBytes uint64 `json:"tx_bytes"`
+	} `json:"networks"`
+}
+
+// listContainers returns every container, running or not
+func (c *dockerClient) listContainers(ctx context.Context) ([]apiContainer, error) {
+	var containers []apiContainer
+	err := c.get(ctx, "/containers/json?all=true", &containers)
+	return containers, err
+}
+
+// inspectContainer returns the details of a container
+func (c *dockerClient) inspectContainer(ctx context.Context, id string) (apiContainerDetails, error) {
+	var details apiContainerDetails
+	err := c.get(ctx, "/containers/"+id+"/json", &details)
+	return details, err
+}
+
+// containerStats returns a single resource usage sample of a running container
+// one-shot skips the second sample Docker otherwise takes to fill in precpu_stats,
+// rates are calculated from the previous collection instead.
+func (c *dockerClient) containerStats(ctx context.Context, id string) (apiContainerStats, error) {
+	var stats apiContainerStats
+	err := c.get(ctx, "/containers/"+id+"/stats?stream=false&one-shot=true", &stats)
+	return stats, err
+}
@@ -16,101 +16,112 @@ package system
 // along with this program. If not, see <https://www.gnu.org/licenses/>.
 
 import (
-	"runtime"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
 )
 
 type FeatureToggleStruct struct {
 	// Feature toggles
 	DisableCPULoad     bool // disable CPU load monitoring
+	DisableCPUUsage    bool // disable CPU utilisation monitoring
 	DisableTemperature bool // disable temperature monitoring
+	DisableSensors     bool // disable thermal zone and hardware sensor monitoring
 	DisableMemory      bool // disable memory monitoring
 	DisableSwap        bool // disable swap monitoring
 	DisableDisk        bool // disable disk monitoring
+	DisableDiskIO      bool // disable disk I/O monitoring
 	DisableHost        bool // disable host information
+	DisableNetwork     bool // disable network monitoring
+	DisablePressure    bool // disable pressure stall information
+	DisableProcesses   bool // disable top process monitoring
+	DisableContainers  bool // disable container monitoring
 }
 
-var disabledFeatures FeatureToggleStruct
-
 func SetFeatureToggles(t FeatureToggleStruct) {
-	disabledFeatures = t
+	updateSettings(func(s *Settings) {
+		s.Features = t
+	})
 }
 
 // GetSystemInfo collects and returns comprehensive system information
 func GetSystemInfo() (*SystemInfo, error) {
-	var hostname, platform string
-	var bootTime int64
-	if !disabledFeatures.DisableHost {
-		// Gather host information
-		var err error
-		hostname, platform, bootTime, err = getHostInfo()
-		if err != nil {
+	return CollectSystemInfo(context.Background())
+}
+
+// CollectSystemInfo runs every registered collector that is enabled and returns the combined result
+// A failing collector does not fail the whole result; its error is recorded in info.Errors
+// and info.Degraded is set, while the sections that succeeded are still returned.
+func CollectSystemInfo(ctx context.Context) (*SystemInfo, error) {
+	info := newSystemInfo(time.Now().Unix())
+	for _, c := range GetCollectors() {
+		if err := runCollector(ctx, c, info); err != nil {
 			return nil, err
 		}
 	}
+	return info, nil
+}
 
-	// Gather memory information
-	memInfo, err := getMemoryInfo()
-	if err != nil {
-		return nil, err
-	}
-
-	// Get filesystem mount points and usage
-	mountPoints, err := getMountPoints()
-	if err != nil {
-		return nil, err
-	}
-
-	load1Percent := 0
-	load15Percent := 0
-	if !disabledFeatures.DisableCPULoad {
-		// Get number of CPU cores for load percentage calculation
-		cpuCount := runtime.NumCPU()
-
-		// Get CPU load averages
-		load1, load15, err := getLoadAverage()
-		if err != nil {
-			return nil, err
+// CollectSections runs only the named collectors and returns the combined result
+// Sections that are not requested are left empty and marked as unavailable.
+func CollectSections(ctx context.Context, names []string) (*SystemInfo, error) {
+	info := newSystemInfo(time.Now().Unix())
+	for _, name := range names {
+		c, exists := GetCollector(name)
+		if !exists {
+			return nil, fmt.Errorf("unknown section %q", name)
 		}
-
-		// Calculate load percentages based on CPU count
-		// Load average of 1.0 = 100% utilization on single-core system
-		load1Percent = int((load1 / float64(cpuCount)) * 100)
-		if load1Percent > 100 {
-			load1Percent = 100 // Cap at 100%
+		if err := runCollector(ctx, c, info); err != nil {
+			return nil, err
 		}
+	}
+	return info, nil
+}
 
-		load15Percent = int((load15 / float64(cpuCount)) * 100)
-		if load15Percent > 100 {
-			load15Percent = 100 // Cap at 100%
-		}
+// newSystemInfo returns an empty SystemInfo collected at the given Unix timestamp
+func newSystemInfo(collectedAt int64) *SystemInfo {
+	return &SystemInfo{
+		CollectedAt:  collectedAt,
+		MountPoints:  []MountPoint{},
+		BlockDevices: []DiskIO{},
+		Network:      NetworkInfo{Interfaces: []NetworkInterface{}},
+		Sensors:      SensorsInfo{ThermalZones: []ThermalZone{}, Hwmon: []HwmonChip{}},
 	}
+}
 
-	CPUTempIsAvailable := false
-	CPUTemp := 0
-	if !disabledFeatures.DisableTemperature {
-		CPUTempIsAvailable = true
-		CPUTemp = getCPUTemperature()
-		if CPUTemp <= 0 {
-			CPUTempIsAvailable = false // If temperature is negative, assume not available
-		}
+// runCollector runs a single collector if it is enabled and records any error in info
+// Only returns an error if the collection was cancelled
+func runCollector(ctx context.Context, c Collector, info *SystemInfo) error {
+	if err := ctx.Err(); err != nil {
+		return err // Collection was cancelled
 	}
+	if !c.Enabled() {
+		return nil // Skip sections disabled by the feature toggles
+	}
+	if err := c.Collect(ctx, info); err != nil {
+		// Log detailed error server-side and keep collecting the other sections
+		log.Printf("Collector %s error: %v", c.Name(), err)
+		info.setError(c.Name(), err.Error())
+	}
+	return nil
+}
 
-	// Assemble complete system information
-	info := &SystemInfo{
-		HostInfoIsAvailable: !disabledFeatures.DisableHost,
-		BootTime:            bootTime,
-		Hostname:            hostname,
-		Platform:            platform,
-		CPU: CPUInfo{
-			LoadIsAvailable:        !disabledFeatures.DisableCPULoad,
-			Load1Percent:           load1Percent,
-			Load15Percent:          load15Percent,
-			TemperatureIsAvailable: CPUTempIsAvailable,
-			TemperatureC:           CPUTemp,
-		},
-		Memory:      memInfo,
-		MountPoints: mountPoints,
+// setError records the error for a section and marks the information as degraded
+func (info *SystemInfo) setError(section, message string) {
+	if info.Errors == nil {
+		info.Errors = make(map[string]string)
 	}
+	info.Errors[section] = message
+	info.Degraded = true
+}
 
-	return info, nil
+// ratePerSecond calculates the per-second rate between two counter readings
+// Returns 0 if the counter was reset or no time has elapsed
+func ratePerSecond(previous, current uint64, elapsedSeconds float64) float64 {
+	if current < previous || elapsedSeconds <= 0 {
+		return 0
+	}
+	return math.Round(float64(current-previous)/elapsedSeconds*100) / 100 // Round to 2 decimal places
 }
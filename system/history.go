@@ -0,0 +1,331 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrUnknownMetric is returned when no history is recorded for a metric
+var ErrUnknownMetric = errors.New("unknown metric")
+
+// HistoryPoint is a downsampled value of a metric
+type HistoryPoint struct {
+	Timestamp int64   `json:"timestamp"` // Start of the interval as Unix timestamp
+	Min       float64 `json:"min"`       // Lowest sample in the interval
+	Avg       float64 `json:"avg"`       // Average of the samples in the interval
+	Max       float64 `json:"max"`       // Highest sample in the interval
+}
+
+// HistorySeries is the history of a metric over a time range
+type HistorySeries struct {
+	Metric            string         `json:"metric"`             // Metric name, e.g. "cpu.load1_percent"
+	RangeSeconds      int64          `json:"range_seconds"`      // Requested time range in seconds
+	ResolutionSeconds int64          `json:"resolution_seconds"` // Length of the interval each point covers
+	Points            []HistoryPoint `json:"points"`             // Points from oldest to newest, the last one may still be filling
+}
+
+// historyTier is a resolution at which history is kept
+type historyTier struct {
+	resolution time.Duration // Interval covered by each point
+	capacity   int           // Number of points kept
+}
+
+// historyTiers lists the resolutions from finest to coarsest
+var historyTiers = []historyTier{
+	{resolution: 10 * time.Second, capacity: 360}, // 1 hour
+	{resolution: time.Minute, capacity: 1440},     // 24 hours
+	{resolution: 15 * time.Minute, capacity: 672}, // 7 days
+}
+
+// historyPointBytes is the memory used by a single point
+const historyPointBytes = 32
+
+// historySeriesBytes is the memory used by the history of a single metric
+var historySeriesBytes = func() int {
+	points := 0
+	for _, tier := range historyTiers {
+		points += tier.capacity
+	}
+	return points * historyPointBytes
+}()
+
+// historyRetention is the longest time range kept in the history
+var historyRetention = func() time.Duration {
+	longest := historyTiers[len(historyTiers)-1]
+	return longest.resolution * time.Duration(longest.capacity)
+}()
+
+// historyBucket accumulates the samples of the point that is being filled
+type historyBucket struct {
+	start int64 // Start of the interval as Unix timestamp
+	min   float64
+	max   float64
+	sum   float64
+	count int
+}
+
+// point returns the downsampled value of the bucket
+func (b historyBucket) point() HistoryPoint {
+	return HistoryPoint{Timestamp: b.start, Min: b.min, Avg: b.sum / float64(b.count), Max: b.max}
+}
+
+// historyRing is a fixed-size ring buffer of points at one resolution
+type historyRing struct {
+	tier    historyTier
+	points  []HistoryPoint // Completed points, allocated up front so memory use is fixed
+	next    int            // Index the next completed point is written to
+	count   int            // Number of completed points
+	current historyBucket  // Point that is being filled, count is 0 when empty
+}
+
+// add records a sample, completing the current point when the sample starts a new interval
+func (r *historyRing) add(at time.Time, value float64) {
+	start := at.Truncate(r.tier.resolution).Unix()
+	if r.current.count > 0 && r.current.start != start {
+		r.points[r.next] = r.current.point()
+		r.next = (r.next + 1) % len(r.points)
+		r.count = min(r.count+1, len(r.points))
+		r.current = historyBucket{}
+	}
+
+	if r.current.count == 0 {
+		r.current = historyBucket{start: start, min: value, max: value}
+	}
+	r.current.min = min(r.current.min, value)
+	r.current.max = max(r.current.max, value)
+	r.current.sum += value
+	r.current.count++
+}
+
+// since returns the points starting at or after the given Unix timestamp, oldest first
+func (r *historyRing) since(from int64) []HistoryPoint {
+	points := []HistoryPoint{}
+	for i := 0; i < r.count; i++ {
+		point := r.points[(r.next-r.count+i+len(r.points))%len(r.points)]
+		if point.Timestamp >= from {
+			points = append(points, point)
+		}
+	}
+	if r.current.count > 0 && r.current.start >= from {
+		points = append(points, r.current.point())
+	}
+	return points
+}
+
+// historySeries holds the history of a metric at every resolution
+type historySeries struct {
+	rings   []*historyRing
+	updated time.Time // When the last sample was recorded
+}
+
+// newHistorySeries returns an empty series with a ring for each tier
+func newHistorySeries() *historySeries {
+	series := &historySeries{}
+	for _, tier := range historyTiers {
+		series.rings = append(series.rings, &historyRing{tier: tier, points: make([]HistoryPoint, tier.capacity)})
+	}
+	return series
+}
+
+// history holds the series of every recorded metric
+var history = struct {
+	sync.Mutex
+	series  map[string]*historySeries
+	order   []string // Metric names in the order they were first recorded
+	dropped bool     // Whether metrics were skipped because the memory limit was reached
+}{series: make(map[string]*historySeries)}
+
+// recordHistory adds the metrics of a snapshot to the history
+// Containers, interfaces and mounts come and go, so metrics without a sample for longer than
+// the retention are forgotten. When the memory limit is reached the least recently updated
+// metric makes room for a new one, and the most recently added metrics are dropped when the
+// limit is lowered.
+func recordHistory(info *SystemInfo, at time.Time) {
+	maxSeries := getSettings().HistoryMaxMemoryMB * 1024 * 1024 / historySeriesBytes
+
+	history.Lock()
+	defer history.Unlock()
+
+	for len(history.order) > maxSeries {
+		last := history.order[len(history.order)-1]
+		delete(history.series, last)
+		history.order = history.order[:len(history.order)-1]
+	}
+	if maxSeries == 0 || info == nil {
+		return
+	}
+	for name, series := range history.series {
+		if at.Sub(series.updated) > historyRetention {
+			removeHistorySeries(name)
+		}
+	}
+
+	values := historyValues(info)
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		value := values[name]
+		series, exists := history.series[name]
+		if !exists {
+			if len(history.order) >= maxSeries && !evictStaleHistorySeries(at) {
+				if !history.dropped {
+					log.Printf("History memory limit reached, %s and further metrics are not recorded", name)
+					history.dropped = true
+				}
+				continue
+			}
+			series = newHistorySeries()
+			history.series[name] = series
+			history.order = append(history.order, name)
+		}
+		for _, ring := range series.rings {
+			ring.add(at, value)
+		}
+		series.updated = at
+	}
+}
+
+// evictStaleHistorySeries removes the least recently updated metric to make room for a new one
+// Metrics updated by the snapshot being recorded are kept, returns whether a metric was removed.
+// The history lock must be held.
+func evictStaleHistorySeries(at time.Time) bool {
+	var stalest string
+	for name, series := range history.series {
+		if series.updated.Before(at) && (stalest == "" || series.updated.Before(history.series[stalest].updated)) {
+			stalest = name
+		}
+	}
+	if stalest == "" {
+		return false
+	}
+	removeHistorySeries(stalest)
+	return true
+}
+
+// removeHistorySeries forgets the history of a metric
+// The history lock must be held.
+func removeHistorySeries(name string) {
+	delete(history.series, name)
+	if i := slices.Index(history.order, name); i >= 0 {
+		history.order = slices.Delete(history.order, i, i+1)
+	}
+}
+
+// historyValues returns the metrics recorded in the history for a snapshot, keyed by name
+// Sections that are not available are left out.
+func historyValues(info *SystemInfo) map[string]float64 {
+	values := make(map[string]float64)
+	if info.CPU.LoadIsAvailable {
+		values["cpu.load1_percent"] = float64(info.CPU.Load1Percent)
+		values["cpu.load15_percent"] = float64(info.CPU.Load15Percent)
+	}
+	if info.CPU.UsageIsAvailable {
+		values["cpu.usage.used_percent"] = info.CPU.Usage.UsedPercent
+		values["cpu.usage.user_percent"] = info.CPU.Usage.UserPercent
+		values["cpu.usage.system_percent"] = info.CPU.Usage.SystemPercent
+		values["cpu.usage.iowait_percent"] = info.CPU.Usage.IOWaitPercent
+		values["cpu.usage.steal_percent"] = info.CPU.Usage.StealPercent
+	}
+	if info.CPU.TemperatureIsAvailable {
+		values["cpu.temperature_c"] = float64(info.CPU.TemperatureC)
+	}
+	if info.Memory.MemoryIsAvailable {
+		values["memory.used_mb"] = float64(info.Memory.UsedMB)
+		values["memory.used_percent"] = float64(info.Memory.UsedPercent)
+	}
+	if info.Memory.SwapIsAvailable {
+		values["memory.swap_used_mb"] = float64(info.Memory.SwapUsedMB)
+		values["memory.swap_used_percent"] = float64(info.Memory.SwapUsedPercent)
+	}
+	if info.Pressure.PressureIsAvailable {
+		values["pressure.cpu.some_avg10"] = info.Pressure.CPU.Some.Avg10
+		values["pressure.memory.some_avg10"] = info.Pressure.Memory.Some.Avg10
+		values["pressure.memory.full_avg10"] = info.Pressure.Memory.Full.Avg10
+		values["pressure.io.some_avg10"] = info.Pressure.IO.Some.Avg10
+		values["pressure.io.full_avg10"] = info.Pressure.IO.Full.Avg10
+	}
+	if info.Cgroup.CgroupIsAvailable {
+		values["cgroup.memory_used_mb"] = float64(info.Cgroup.MemoryUsedMB)
+		values["cgroup.memory_used_percent"] = float64(info.Cgroup.MemoryUsedPercent)
+		values["cgroup.cpu_usage_percent"] = info.Cgroup.CPUUsagePercent
+	}
+	for _, mount := range info.MountPoints {
+		values[fmt.Sprintf("mountpoints[%s].used_mb", mount.Path)] = float64(mount.UsedMB)
+		values[fmt.Sprintf("mountpoints[%s].used_percent", mount.Path)] = float64(mount.UsedPercent)
+	}
+	if info.Network.NetworkIsAvailable {
+		for _, iface := range info.Network.Interfaces {
+			values[fmt.Sprintf("network[%s].rx_bytes_per_sec", iface.Name)] = iface.RxBytesPerSec
+			values[fmt.Sprintf("network[%s].tx_bytes_per_sec", iface.Name)] = iface.TxBytesPerSec
+		}
+	}
+	for _, container := range info.Containers.Containers {
+		if container.StatsIsAvailable {
+			values[fmt.Sprintf("containers[%s].cpu_percent", container.Name)] = container.CPUPercent
+			values[fmt.Sprintf("containers[%s].memory_used_mb", container.Name)] = float64(container.MemoryUsedMB)
+		}
+	}
+	return values
+}
+
+// GetHistoryMetrics returns the names of the metrics with recorded history, sorted
+func GetHistoryMetrics() []string {
+	history.Lock()
+	defer history.Unlock()
+
+	return slices.Sorted(slices.Values(history.order))
+}
+
+// GetHistory returns the history of a metric over the given time range
+// The finest resolution that covers the whole range is used.
+func GetHistory(metric string, timeRange time.Duration) (HistorySeries, error) {
+	if timeRange <= 0 {
+		return HistorySeries{}, fmt.Errorf("range must be positive")
+	}
+
+	tier := -1
+	for i, t := range historyTiers {
+		if t.resolution*time.Duration(t.capacity) >= timeRange {
+			tier = i
+			break
+		}
+	}
+	if tier < 0 {
+		longest := historyTiers[len(historyTiers)-1]
+		return HistorySeries{}, fmt.Errorf("range must not be longer than %s", longest.resolution*time.Duration(longest.capacity))
+	}
+
+	history.Lock()
+	defer history.Unlock()
+
+	series, exists := history.series[metric]
+	if !exists {
+		return HistorySeries{}, ErrUnknownMetric
+	}
+	ring := series.rings[tier]
+	from := time.Now().Add(-timeRange).Truncate(ring.tier.resolution).Unix()
+	return HistorySeries{
+		Metric:            metric,
+		RangeSeconds:      int64(timeRange.Seconds()),
+		ResolutionSeconds: int64(ring.tier.resolution.Seconds()),
+		Points:            ring.since(from),
+	}, nil
+}
@@ -0,0 +1,235 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// resetHistory starts a test with an empty history and clears it again afterwards
+func resetHistory(t *testing.T) {
+	t.Helper()
+	clear := func() {
+		history.Lock()
+		history.series = make(map[string]*historySeries)
+		history.order = nil
+		history.dropped = false
+		history.Unlock()
+	}
+	clear()
+	t.Cleanup(clear)
+}
+
+// historySnapshot returns a snapshot with the memory metrics and the named containers
+func historySnapshot(usedPercent int, containers ...string) *SystemInfo {
+	info := newSystemInfo(0)
+	info.Memory.MemoryIsAvailable = true
+	info.Memory.UsedPercent = usedPercent
+	info.Memory.UsedMB = usedPercent * 10
+	for _, name := range containers {
+		info.Containers.Containers = append(info.Containers.Containers, Container{Name: name, StatsIsAvailable: true, CPUPercent: 1})
+	}
+	return info
+}
+
+func TestGetHistoryTiers(t *testing.T) {
+	resetHistory(t)
+	setTestSettings(t, func(s *Settings) { s.HistoryMaxMemoryMB = 16 })
+
+	// Three hours of samples every 10 seconds, alternating between 10 and 30,
+	// so every 10 second point holds one sample and coarser points average to 20
+	now := time.Now()
+	for i := 0; i <= 3*360; i++ {
+		value := 10
+		if i%2 == 1 {
+			value = 30
+		}
+		recordHistory(historySnapshot(value), now.Add(-3*time.Hour+time.Duration(i)*10*time.Second))
+	}
+
+	tests := []struct {
+		name       string
+		timeRange  time.Duration
+		resolution int64
+		minPoints  int
+		maxPoints  int
+		wantMin    float64 // Expected values of the points that cover a whole interval
+		wantAvg    float64
+		wantMax    float64
+	}{
+		{name: "10 minutes", timeRange: 10 * time.Minute, resolution: 10, minPoints: 60, maxPoints: 61},
+		{name: "1 hour", timeRange: time.Hour, resolution: 10, minPoints: 360, maxPoints: 361},
+		{name: "6 hours", timeRange: 6 * time.Hour, resolution: 60, minPoints: 180, maxPoints: 181, wantMin: 10, wantAvg: 20, wantMax: 30},
+		{name: "7 days", timeRange: 7 * 24 * time.Hour, resolution: 900, minPoints: 12, maxPoints: 13, wantMin: 10, wantAvg: 20, wantMax: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series, err := GetHistory("memory.used_percent", tt.timeRange)
+			if err != nil {
+				t.Fatalf("GetHistory() error = %v", err)
+			}
+			if series.ResolutionSeconds != tt.resolution || series.RangeSeconds != int64(tt.timeRange.Seconds()) {
+				t.Errorf("resolution %ds over %ds, want %ds over %ds", series.ResolutionSeconds, series.RangeSeconds, tt.resolution, int64(tt.timeRange.Seconds()))
+			}
+			if n := len(series.Points); n < tt.minPoints || n > tt.maxPoints {
+				t.Fatalf("got %d points, want %d to %d", n, tt.minPoints, tt.maxPoints)
+			}
+
+			from := now.Add(-tt.timeRange).Truncate(time.Duration(tt.resolution) * time.Second).Unix()
+			for i, point := range series.Points {
+				if point.Timestamp < from || point.Timestamp%tt.resolution != 0 {
+					t.Errorf("point %d at %d, want a multiple of %d from %d", i, point.Timestamp, tt.resolution, from)
+				}
+				if i > 0 && point.Timestamp <= series.Points[i-1].Timestamp {
+					t.Errorf("point %d at %d is not after the previous point", i, point.Timestamp)
+				}
+				if tt.resolution == 10 {
+					// A single sample per point
+					if point.Min != point.Max || point.Avg != point.Min || (point.Min != 10 && point.Min != 30) {
+						t.Errorf("point %d = %+v, want a single sample of 10 or 30", i, point)
+					}
+					continue
+				}
+				// The first and last points only cover part of their interval
+				if i > 0 && i < len(series.Points)-1 && (point.Min != tt.wantMin || point.Avg != tt.wantAvg || point.Max != tt.wantMax) {
+					t.Errorf("point %d = %+v, want min %v avg %v max %v", i, point, tt.wantMin, tt.wantAvg, tt.wantMax)
+				}
+			}
+		})
+	}
+}
+
+func TestGetHistoryErrors(t *testing.T) {
+	resetHistory(t)
+	setTestSettings(t, func(s *Settings) { s.HistoryMaxMemoryMB = 16 })
+	recordHistory(historySnapshot(10), time.Now())
+
+	tests := []struct {
+		name      string
+		metric    string
+		timeRange time.Duration
+		wantErr   error
+	}{
+		{name: "unknown metric", metric: "memory.free_percent", timeRange: time.Hour, wantErr: ErrUnknownMetric},
+		{name: "range not positive", metric: "memory.used_percent", timeRange: 0},
+		{name: "range beyond retention", metric: "memory.used_percent", timeRange: 8 * 24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := GetHistory(tt.metric, tt.timeRange)
+			if err == nil {
+				t.Fatal("GetHistory() error = nil")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("GetHistory() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecordHistoryEviction(t *testing.T) {
+	// Each snapshot records 2 memory metrics and 2 per container, 1 MB holds 13 metrics
+	maxSeries := 1024 * 1024 / historySeriesBytes
+	start := time.Now().Add(-8 * 24 * time.Hour)
+
+	tests := []struct {
+		name      string
+		snapshots [][]string // Containers of each snapshot, recorded an hour apart
+		last      time.Time  // When the last snapshot is recorded, an hour after the previous one when zero
+		memoryMB  int        // History memory limit when the last snapshot is recorded
+		want      int        // Number of metrics kept
+		kept      []string   // Metrics that must be kept
+		evicted   []string   // Metrics that must be forgotten
+	}{
+		{
+			name:      "below the limit",
+			snapshots: [][]string{{"a", "b"}, {"a", "b"}},
+			memoryMB:  1,
+			want:      6,
+			kept:      []string{"memory.used_percent", "containers[a].cpu_percent", "containers[b].memory_used_mb"},
+		},
+		{
+			name:      "limit reached by a single snapshot",
+			snapshots: [][]string{{"a", "b", "c", "d", "e", "f"}},
+			memoryMB:  1,
+			want:      maxSeries,
+			kept:      []string{"containers[a].cpu_percent", "memory.used_mb"},
+			evicted:   []string{"memory.used_percent"}, // Metrics are added in name order
+		},
+		{
+			name:      "stale metrics make room",
+			snapshots: [][]string{{"a", "b", "c", "d", "e"}, {"f"}},
+			memoryMB:  1,
+			want:      maxSeries,
+			kept:      []string{"containers[f].cpu_percent", "containers[f].memory_used_mb", "memory.used_percent"},
+		},
+		{
+			name:      "metrics without samples beyond the retention",
+			snapshots: [][]string{{"a"}, {"b"}},
+			last:      start.Add(historyRetention + 2*time.Hour),
+			memoryMB:  1,
+			want:      4,
+			kept:      []string{"containers[b].cpu_percent"},
+			evicted:   []string{"containers[a].cpu_percent", "containers[a].memory_used_mb"},
+		},
+		{
+			name:      "limit lowered",
+			snapshots: [][]string{{"a", "b", "c", "d", "e"}, {"a"}},
+			memoryMB:  0,
+			want:      0,
+			evicted:   []string{"memory.used_percent"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetHistory(t)
+			setTestSettings(t, func(s *Settings) { s.HistoryMaxMemoryMB = 1 })
+
+			at := start
+			for i, containers := range tt.snapshots {
+				if i == len(tt.snapshots)-1 {
+					updateSettings(func(s *Settings) { s.HistoryMaxMemoryMB = tt.memoryMB })
+					if !tt.last.IsZero() {
+						at = tt.last
+					}
+				}
+				recordHistory(historySnapshot(10, containers...), at)
+				at = at.Add(time.Hour)
+			}
+
+			metrics := GetHistoryMetrics()
+			if len(metrics) != tt.want {
+				t.Errorf("kept %d metrics, want %d: %v", len(metrics), tt.want, metrics)
+			}
+			for _, name := range tt.kept {
+				if !slices.Contains(metrics, name) {
+					t.Errorf("%s was not kept: %v", name, metrics)
+				}
+			}
+			for _, name := range tt.evicted {
+				if slices.Contains(metrics, name) {
+					t.Errorf("%s was kept: %v", name, metrics)
+				}
+			}
+		})
+	}
+}
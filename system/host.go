@@ -0,0 +1,57 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "context"
+
+func init() {
+	RegisterCollector(hostCollector{})
+}
+
+// hostCollector gathers hostname, platform and boot time
+type hostCollector struct{}
+
+// Name returns the section name
+func (hostCollector) Name() string {
+	return "host"
+}
+
+// Enabled reports whether host information is enabled
+func (hostCollector) Enabled() bool {
+	return !features().DisableHost
+}
+
+// Collect gathers host information into info
+func (hostCollector) Collect(_ context.Context, info *SystemInfo) error {
+	hostname, platform, bootTime, err := getHostInfo()
+	if err != nil {
+		return err
+	}
+
+	info.HostInfoIsAvailable = true
+	info.Hostname = hostname
+	info.Platform = platform
+	info.BootTime = bootTime
+	return nil
+}
+
+// CopySection copies host information from src into dst
+func (hostCollector) CopySection(dst, src *SystemInfo) {
+	dst.HostInfoIsAvailable = src.HostInfoIsAvailable
+	dst.Hostname = src.Hostname
+	dst.Platform = src.Platform
+	dst.BootTime = src.BootTime
+}
@@ -26,14 +26,14 @@ import (
 // getHostInfo retrieves hostname, platform information, and boot time
 func getHostInfo() (string, string, int64, error) {
 	// Get system hostname
-	hostname, err := os.Hostname()
+	hostname, err := getHostname()
 	if err != nil {
 		return "", "", 0, err
 	}
 
 	// Get platform/OS information from /etc/os-release
 	platform := "Linux" // Default fallback
-	if data, err := os.ReadFile("/etc/os-release"); err == nil {
+	if data, err := os.ReadFile(etcPath("os-release")); err == nil {
 		lines := strings.Split(string(data), "\n")
 		for _, line := range lines {
 			if strings.HasPrefix(line, "PRETTY_NAME=") {
@@ -46,7 +46,7 @@ func getHostInfo() (string, string, int64, error) {
 
 	// Get system boot time from /proc/stat
 	bootTime := int64(0)
-	if data, err := os.ReadFile("/proc/stat"); err == nil {
+	if data, err := os.ReadFile(procPath("stat")); err == nil {
 		lines := strings.Split(string(data), "\n")
 		for _, line := range lines {
 			if strings.HasPrefix(line, "btime ") {
@@ -61,3 +61,17 @@ func getHostInfo() (string, string, int64, error) {
 
 	return hostname, platform, bootTime, nil
 }
+
+// getHostname returns the hostname of the system
+// The hostname belongs to the UTS namespace of the agent, so when the host's /etc is mounted
+// elsewhere the hostname is read from its hostname file instead.
+func getHostname() (string, error) {
+	if getSettings().HostPaths.Etc != defaultHostPaths.Etc {
+		if data, err := os.ReadFile(etcPath("hostname")); err == nil {
+			if hostname := strings.TrimSpace(string(data)); hostname != "" {
+				return hostname, nil
+			}
+		}
+	}
+	return os.Hostname()
+}
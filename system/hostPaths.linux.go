@@ -0,0 +1,51 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "path/filepath"
+
+// procPath returns the path of a file in the host's /proc, e.g. procPath("meminfo")
+func procPath(elem ...string) string {
+	return filepath.Join(append([]string{getSettings().HostPaths.Proc}, elem...)...)
+}
+
+// procProcessPath returns the path of a per-process file in the host's /proc, e.g. "mounts"
+// Files such as /proc/mounts and /proc/net/dev describe the namespaces of the reading process,
+// so when the host's /proc is mounted elsewhere the files of the host's init process are read.
+func procProcessPath(elem ...string) string {
+	hostPaths := getSettings().HostPaths
+	if hostPaths.Proc == defaultHostPaths.Proc {
+		return filepath.Join(append([]string{hostPaths.Proc, "self"}, elem...)...)
+	}
+	return filepath.Join(append([]string{hostPaths.Proc, "1"}, elem...)...)
+}
+
+// sysPath returns the path of a file in the host's /sys, e.g. sysPath("class", "thermal")
+func sysPath(elem ...string) string {
+	return filepath.Join(append([]string{getSettings().HostPaths.Sys}, elem...)...)
+}
+
+// etcPath returns the path of a file in the host's /etc, e.g. etcPath("os-release")
+func etcPath(elem ...string) string {
+	return filepath.Join(append([]string{getSettings().HostPaths.Etc}, elem...)...)
+}
+
+// rootPath returns the path of a host path, such as a mountpoint, under the host's root
+func rootPath(path string) string {
+	return filepath.Join(getSettings().HostPaths.Root, path)
+}
@@ -0,0 +1,53 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "context"
+
+func init() {
+	RegisterCollector(memoryCollector{})
+}
+
+// memoryCollector gathers memory and swap usage
+type memoryCollector struct{}
+
+// Name returns the section name
+func (memoryCollector) Name() string {
+	return "memory"
+}
+
+// Enabled reports whether memory or swap monitoring is enabled
+func (memoryCollector) Enabled() bool {
+	return !features().DisableMemory || !features().DisableSwap
+}
+
+// Collect gathers memory and swap usage into info.Memory
+func (memoryCollector) Collect(_ context.Context, info *SystemInfo) error {
+	memInfo, err := getMemoryInfo()
+	if err != nil {
+		return err
+	}
+	if getSettings().CgroupAware && memInfo.MemoryIsAvailable {
+		applyCgroupMemoryLimit(&memInfo)
+	}
+	info.Memory = memInfo
+	return nil
+}
+
+// CopySection copies memory and swap usage from src into dst
+func (memoryCollector) CopySection(dst, src *SystemInfo) {
+	dst.Memory = src.Memory
+}
@@ -19,6 +19,7 @@ package system
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -37,12 +38,12 @@ func getMemoryInfo() (MemoryInfo, error) {
 		SwapUsedMB:        0,
 		SwapUsedPercent:   0,
 	}
-	if disabledFeatures.DisableMemory && disabledFeatures.DisableSwap {
+	if features().DisableMemory && features().DisableSwap {
 		return memoryInfo, nil // Skip if both memory and swap monitoring are disabled
 	}
 
 	// Read the memory and swap information from /proc/meminfo
-	file, err := os.Open("/proc/meminfo")
+	file, err := os.Open(procPath("meminfo"))
 	if err != nil {
 		return MemoryInfo{}, err
 	}
@@ -69,7 +70,7 @@ func getMemoryInfo() (MemoryInfo, error) {
 	}
 
 	// Only proceed if we are checking memory
-	if !disabledFeatures.DisableMemory {
+	if !features().DisableMemory {
 		// Calculate memory usage statistics
 		totalMB := int(memInfo["MemTotal"] / (1024 * 1024))
 		memoryIsAvailable := memInfo["MemTotal"] > 0
@@ -99,7 +100,7 @@ func getMemoryInfo() (MemoryInfo, error) {
 	}
 
 	// Only proceed if we are checking swap
-	if !disabledFeatures.DisableSwap {
+	if !features().DisableSwap {
 		// Calculate swap usage statistics
 		swapTotalMB := int(memInfo["SwapTotal"] / (1024 * 1024))
 		swapFreeMB := int(memInfo["SwapFree"] / (1024 * 1024))
@@ -120,3 +121,23 @@ func getMemoryInfo() (MemoryInfo, error) {
 
 	return memoryInfo, nil
 }
+
+// readSystemMemory returns the total system memory in bytes from /proc/meminfo
+func readSystemMemory() (uint64, error) {
+	stat, err := os.ReadFile(procPath("meminfo"))
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(stat), "\n") {
+		// /proc/meminfo format: "MemTotal:       16384256 kB"
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			total, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid MemTotal value: %w", err)
+			}
+			return total * 1024, nil // Convert from kB to bytes
+		}
+	}
+	return 0, errors.New("no MemTotal found in /proc/meminfo")
+}
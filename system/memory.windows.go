@@ -37,12 +37,12 @@ func getMemoryInfo() (MemoryInfo, error) {
 		SwapUsedPercent:   0,
 	}
 
-	if disabledFeatures.DisableMemory && disabledFeatures.DisableSwap {
+	if features().DisableMemory && features().DisableSwap {
 		return memoryInfo, nil
 	}
 
 	// Get physical memory info
-	if !disabledFeatures.DisableMemory {
+	if !features().DisableMemory {
 		cmd := exec.Command("wmic", "OS", "get", "TotalVisibleMemorySize,FreePhysicalMemory", "/format:list")
 		output, err := cmd.Output()
 		if err != nil {
@@ -78,7 +78,7 @@ func getMemoryInfo() (MemoryInfo, error) {
 	}
 
 	// Get page file (swap) info
-	if !disabledFeatures.DisableSwap {
+	if !features().DisableSwap {
 		cmd := exec.Command("wmic", "pagefile", "get", "AllocatedBaseSize,CurrentUsage", "/format:list")
 		output, err := cmd.Output()
 		if err == nil {
@@ -0,0 +1,100 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"context"
+	"path"
+	"slices"
+)
+
+func init() {
+	RegisterCollector(networkCollector{})
+}
+
+// ignoredInterfaces defines network interfaces ignored by default
+// Only applied when no include list is configured
+var ignoredInterfaces = []string{
+	"lo",
+}
+
+// AddIgnoredInterfaces adds additional interface patterns to the ignore list
+// Patterns use shell glob syntax, e.g. "veth*"
+func AddIgnoredInterfaces(patterns []string) {
+	updateSettings(func(s *Settings) {
+		s.IgnoredInterfaces = append(slices.Clone(s.IgnoredInterfaces), patterns...)
+	})
+}
+
+// SetIncludedInterfaces replaces the list of interface patterns to include
+// When set, only matching interfaces are reported and the default ignore list no longer applies
+func SetIncludedInterfaces(patterns []string) {
+	updateSettings(func(s *Settings) {
+		s.IncludedInterfaces = slices.Clone(patterns)
+	})
+}
+
+// shouldIgnoreInterface checks if a network interface should be ignored
+func shouldIgnoreInterface(name string) bool {
+	settings := getSettings()
+	if len(settings.IncludedInterfaces) > 0 {
+		if !matchesAnyPattern(name, settings.IncludedInterfaces) {
+			return true
+		}
+	} else if matchesAnyPattern(name, ignoredInterfaces) {
+		return true
+	}
+
+	return matchesAnyPattern(name, settings.IgnoredInterfaces)
+}
+
+// matchesAnyPattern checks if a name matches any of the glob patterns
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// networkCollector gathers network interface statistics
+type networkCollector struct{}
+
+// Name returns the section name
+func (networkCollector) Name() string {
+	return "network"
+}
+
+// Enabled reports whether network monitoring is enabled
+func (networkCollector) Enabled() bool {
+	return !features().DisableNetwork
+}
+
+// Collect gathers network interface statistics into info.Network
+func (networkCollector) Collect(_ context.Context, info *SystemInfo) error {
+	networkInfo, err := getNetworkInfo()
+	if err != nil {
+		return err
+	}
+	info.Network = networkInfo
+	return nil
+}
+
+// CopySection copies network interface statistics from src into dst
+func (networkCollector) CopySection(dst, src *SystemInfo) {
+	dst.Network = src.Network
+}
@@ -0,0 +1,159 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// interfaceCounters holds the counters of a network interface at a point in time
+type interfaceCounters struct {
+	rxBytes   uint64
+	txBytes   uint64
+	rxPackets uint64
+	txPackets uint64
+	sampledAt time.Time
+}
+
+// networkSampler keeps the previous counters per interface so rates can be computed from deltas
+var networkSampler = struct {
+	sync.Mutex
+	previous map[string]interfaceCounters
+}{}
+
+// getNetworkInfo reads interface counters from /proc/net/dev and link details from /sys/class/net
+func getNetworkInfo() (NetworkInfo, error) {
+	file, err := os.Open(procProcessPath("net", "dev"))
+	if err != nil {
+		return NetworkInfo{}, err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			fmt.Fprintf(os.Stderr, "error closing file: %v\n", cerr)
+		}
+	}()
+
+	now := time.Now()
+	current := make(map[string]interfaceCounters)
+	interfaces := []NetworkInterface{}
+
+	networkSampler.Lock()
+	defer networkSampler.Unlock()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// /proc/net/dev format after two header lines:
+		// "  eth0: 1234 12 0 0 0 0 0 0 5678 34 0 0 0 0 0 0"
+		// Receive: bytes packets errs drop fifo frame compressed multicast
+		// Transmit: bytes packets errs drop fifo colls carrier compressed
+		name, counters, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue // Skip header lines
+		}
+		name = strings.TrimSpace(name)
+		if shouldIgnoreInterface(name) {
+			continue
+		}
+
+		fields := strings.Fields(counters)
+		if len(fields) < 16 {
+			continue // Skip malformed lines
+		}
+
+		values := make([]uint64, 16)
+		for i := range values {
+			values[i], err = strconv.ParseUint(fields[i], 10, 64)
+			if err != nil {
+				return NetworkInfo{}, fmt.Errorf("invalid /proc/net/dev value for %s: %w", name, err)
+			}
+		}
+
+		iface := NetworkInterface{
+			Name:      name,
+			State:     readInterfaceState(name),
+			SpeedMbps: readInterfaceSpeed(name),
+			RxBytes:   values[0],
+			RxPackets: values[1],
+			RxErrors:  values[2],
+			RxDropped: values[3],
+			TxBytes:   values[8],
+			TxPackets: values[9],
+			TxErrors:  values[10],
+			TxDropped: values[11],
+		}
+
+		sample := interfaceCounters{
+			rxBytes:   iface.RxBytes,
+			txBytes:   iface.TxBytes,
+			rxPackets: iface.RxPackets,
+			txPackets: iface.TxPackets,
+			sampledAt: now,
+		}
+		current[name] = sample
+
+		// Calculate rates against the previous sample, if there is one
+		if previous, exists := networkSampler.previous[name]; exists {
+			elapsed := now.Sub(previous.sampledAt).Seconds()
+			iface.RxBytesPerSec = ratePerSecond(previous.rxBytes, sample.rxBytes, elapsed)
+			iface.TxBytesPerSec = ratePerSecond(previous.txBytes, sample.txBytes, elapsed)
+			iface.RxPacketsPerSec = ratePerSecond(previous.rxPackets, sample.rxPackets, elapsed)
+			iface.TxPacketsPerSec = ratePerSecond(previous.txPackets, sample.txPackets, elapsed)
+		}
+
+		interfaces = append(interfaces, iface)
+	}
+	if err := scanner.Err(); err != nil {
+		return NetworkInfo{}, err
+	}
+
+	networkSampler.previous = current
+
+	return NetworkInfo{
+		NetworkIsAvailable: true,
+		Interfaces:         interfaces,
+	}, nil
+}
+
+// readInterfaceState reads the operational state of an interface, e.g. "up" or "down"
+func readInterfaceState(name string) string {
+	data, err := os.ReadFile(sysPath("class", "net", name, "operstate"))
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readInterfaceSpeed reads the link speed of an interface in Mbps
+// Returns 0 for virtual interfaces or links that are down, where the speed is not reported
+func readInterfaceSpeed(name string) int {
+	data, err := os.ReadFile(sysPath("class", "net", name, "speed"))
+	if err != nil {
+		return 0
+	}
+	speed, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || speed < 0 {
+		return 0
+	}
+	return speed
+}
@@ -0,0 +1,50 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "context"
+
+func init() {
+	RegisterCollector(pressureCollector{})
+}
+
+// pressureCollector gathers the Pressure Stall Information for CPU, memory and I/O
+type pressureCollector struct{}
+
+// Name returns the section name
+func (pressureCollector) Name() string {
+	return "pressure"
+}
+
+// Enabled reports whether pressure stall information is enabled
+func (pressureCollector) Enabled() bool {
+	return !features().DisablePressure
+}
+
+// Collect gathers the pressure stall information into info.Pressure
+func (pressureCollector) Collect(_ context.Context, info *SystemInfo) error {
+	pressure, err := getPressureInfo()
+	if err != nil {
+		return err
+	}
+	info.Pressure = pressure
+	return nil
+}
+
+// CopySection copies the pressure stall information from src into dst
+func (pressureCollector) CopySection(dst, src *SystemInfo) {
+	dst.Pressure = src.Pressure
+}
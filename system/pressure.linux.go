@@ -0,0 +1,114 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// getPressureInfo reads the Pressure Stall Information from /proc/pressure
+// Kernels built without PSI, or booted with psi=0, report it as unavailable rather than as an error.
+func getPressureInfo() (PressureInfo, error) {
+	var info PressureInfo
+	for _, resource := range []struct {
+		name     string
+		pressure *Pressure
+	}{
+		{"cpu", &info.CPU},
+		{"memory", &info.Memory},
+		{"io", &info.IO},
+	} {
+		pressure, err := readPressure(procPath("pressure", resource.name))
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.EOPNOTSUPP) {
+			return PressureInfo{}, nil
+		} else if err != nil {
+			return PressureInfo{}, err
+		}
+		*resource.pressure = pressure
+	}
+
+	info.PressureIsAvailable = true
+	return info, nil
+}
+
+// readPressure parses a Pressure Stall Information file, such as /proc/pressure/memory
+// or the memory.pressure file of a cgroup
+func readPressure(path string) (Pressure, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Pressure{}, err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			fmt.Fprintf(os.Stderr, "error closing file: %v\n", cerr)
+		}
+	}()
+
+	var pressure Pressure
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// PSI format: "some avg10=0.00 avg60=0.00 avg300=0.00 total=0"
+		// The "full" line has the same fields and is missing for CPU on kernels before 5.13
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 5 {
+			continue
+		}
+
+		var stats *PressureStats
+		switch fields[0] {
+		case "some":
+			stats = &pressure.Some
+		case "full":
+			stats = &pressure.Full
+		default:
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			if key == "total" {
+				stats.TotalUs, err = strconv.ParseUint(value, 10, 64)
+			} else {
+				var avg float64
+				avg, err = strconv.ParseFloat(value, 64)
+				switch key {
+				case "avg10":
+					stats.Avg10 = avg
+				case "avg60":
+					stats.Avg60 = avg
+				case "avg300":
+					stats.Avg300 = avg
+				}
+			}
+			if err != nil {
+				return Pressure{}, fmt.Errorf("invalid %s value %q: %w", path, field, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Pressure{}, err
+	}
+
+	return pressure, nil
+}
@@ -0,0 +1,50 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "context"
+
+func init() {
+	RegisterCollector(processesCollector{})
+}
+
+// processesCollector gathers the processes using the most CPU and memory
+type processesCollector struct{}
+
+// Name returns the section name
+func (processesCollector) Name() string {
+	return "processes"
+}
+
+// Enabled reports whether top process monitoring is enabled
+func (processesCollector) Enabled() bool {
+	return !features().DisableProcesses
+}
+
+// Collect gathers the top processes into info.Processes
+func (processesCollector) Collect(_ context.Context, info *SystemInfo) error {
+	processes, err := getProcesses()
+	if err != nil {
+		return err
+	}
+	info.Processes = processes
+	return nil
+}
+
+// CopySection copies the top processes from src into dst
+func (processesCollector) CopySection(dst, src *SystemInfo) {
+	dst.Processes = src.Processes
+}
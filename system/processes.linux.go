@@ -0,0 +1,271 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxCommandLineLength is the length command lines are truncated to, scripts passed as arguments can be very long
+const maxCommandLineLength = 512
+
+// processKey identifies a process across samples, the start time guards against PID reuse
+type processKey struct {
+	pid       int
+	startTime uint64
+}
+
+// processStat holds the values of a process read from /proc/[pid]/stat
+type processStat struct {
+	key        processKey
+	name       string
+	cpuTicks   uint64  // CPU time spent in user and kernel mode in clock ticks
+	rssPages   uint64  // Resident memory in pages
+	cpuPercent float64 // CPU usage since the previous sample
+}
+
+// processSampler keeps the previous CPU times so process utilisation can be computed from deltas
+var processSampler = struct {
+	sync.Mutex
+	previous map[processKey]uint64 // CPU ticks of each process
+	total    uint64                // CPU ticks of all cores from /proc/stat
+}{}
+
+// getProcesses reads every process from /proc and returns the top processes by CPU and by memory
+// CPU usage covers the time since the previous call and is 0 on the first call.
+func getProcesses() (ProcessesInfo, error) {
+	entries, err := os.ReadDir(procPath())
+	if err != nil {
+		return ProcessesInfo{}, err
+	}
+	times, _, err := readCPUTimes()
+	if err != nil {
+		return ProcessesInfo{}, err
+	}
+	total := times["cpu"].total()
+
+	stats := make([]processStat, 0, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue // Not a process directory
+		}
+		stat, err := readProcessStat(pid)
+		if err != nil {
+			continue // The process exited while being read
+		}
+		stats = append(stats, stat)
+	}
+
+	current := make(map[processKey]uint64, len(stats))
+	for _, stat := range stats {
+		current[stat.key] = stat.cpuTicks
+	}
+	processSampler.Lock()
+	previous, previousTotal := processSampler.previous, processSampler.total
+	processSampler.previous, processSampler.total = current, total
+	processSampler.Unlock()
+
+	// Processes started since the previous sample have spent all of their CPU time within it
+	if previous != nil && total > previousTotal {
+		delta := float64(total - previousTotal)
+		for i, stat := range stats {
+			if ticks := previous[stat.key]; stat.cpuTicks >= ticks {
+				stats[i].cpuPercent = math.Round(float64(stat.cpuTicks-ticks)/delta*10000) / 100 // Round to 2 decimal places
+			}
+		}
+	}
+
+	systemMemory, err := readSystemMemory()
+	if err != nil {
+		return ProcessesInfo{}, err
+	}
+	resolver := &processResolver{
+		pageSize:     uint64(os.Getpagesize()),
+		systemMemory: systemMemory,
+		redact:       getSettings().RedactCommandLines,
+	}
+
+	top := getSettings().TopProcesses
+	byCPU := slices.Clone(stats)
+	slices.SortStableFunc(byCPU, func(a, b processStat) int {
+		return cmp.Or(cmp.Compare(b.cpuPercent, a.cpuPercent), cmp.Compare(b.rssPages, a.rssPages))
+	})
+	byMemory := slices.Clone(stats)
+	slices.SortStableFunc(byMemory, func(a, b processStat) int {
+		return cmp.Or(cmp.Compare(b.rssPages, a.rssPages), cmp.Compare(b.cpuPercent, a.cpuPercent))
+	})
+
+	return ProcessesInfo{
+		ProcessesIsAvailable: true,
+		Total:                len(stats),
+		TopCPU:               resolver.processes(byCPU[:min(top, len(byCPU))]),
+		TopMemory:            resolver.processes(byMemory[:min(top, len(byMemory))]),
+	}, nil
+}
+
+// readProcessStat reads the name, CPU times and resident memory of a process
+func readProcessStat(pid int) (processStat, error) {
+	data, err := os.ReadFile(procPath(strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return processStat{}, err
+	}
+
+	// /proc/[pid]/stat format: "1234 (name) S 1 1234 1234 0 -1 4194560 ..."
+	// The name can contain spaces and parentheses, so the fields are split after the last ")"
+	start, end := bytes.IndexByte(data, '('), bytes.LastIndexByte(data, ')')
+	if start < 0 || end < start {
+		return processStat{}, fmt.Errorf("invalid stat format for process %d", pid)
+	}
+	fields := strings.Fields(string(data[end+1:]))
+	if len(fields) < 22 {
+		return processStat{}, fmt.Errorf("invalid stat format for process %d", pid)
+	}
+
+	// fields[0] is the state, the third field of the file, so field n is fields[n-3]
+	values := make(map[int]uint64)
+	for _, field := range []int{14, 15, 22, 24} { // utime, stime, starttime, rss
+		values[field], err = strconv.ParseUint(fields[field-3], 10, 64)
+		if err != nil {
+			return processStat{}, fmt.Errorf("invalid stat value for process %d: %w", pid, err)
+		}
+	}
+
+	return processStat{
+		key:      processKey{pid: pid, startTime: values[22]},
+		name:     string(data[start+1 : end]),
+		cpuTicks: values[14] + values[15],
+		rssPages: values[24],
+	}, nil
+}
+
+// processResolver fills in the details of the top processes
+// Owners and command lines are only read for the listed processes.
+type processResolver struct {
+	pageSize     uint64            // Size of a memory page in bytes
+	systemMemory uint64            // Total system memory in bytes
+	redact       bool              // Only report the executable of command lines
+	users        map[string]string // User names keyed by user ID, read on first use
+	resolved     map[int]Process   // Processes already resolved, keyed by PID
+}
+
+// processes returns the details of the given processes
+func (r *processResolver) processes(stats []processStat) []Process {
+	processes := make([]Process, 0, len(stats))
+	for _, stat := range stats {
+		processes = append(processes, r.process(stat))
+	}
+	return processes
+}
+
+// process returns the details of a single process
+func (r *processResolver) process(stat processStat) Process {
+	if process, exists := r.resolved[stat.key.pid]; exists {
+		return process
+	}
+
+	rss := stat.rssPages * r.pageSize
+	process := Process{
+		PID:         stat.key.pid,
+		Name:        stat.name,
+		User:        r.user(stat.key.pid),
+		CommandLine: r.commandLine(stat.key.pid, stat.name),
+		CPUPercent:  stat.cpuPercent,
+		RSSMB:       int(rss / (1024 * 1024)),
+	}
+	if r.systemMemory > 0 {
+		process.MemoryPercent = math.Round(float64(rss)/float64(r.systemMemory)*10000) / 100 // Round to 2 decimal places
+	}
+
+	if r.resolved == nil {
+		r.resolved = make(map[int]Process)
+	}
+	r.resolved[stat.key.pid] = process
+	return process
+}
+
+// user returns the name of the user owning a process, or its user ID if it has no name
+func (r *processResolver) user(pid int) string {
+	data, err := os.ReadFile(procPath(strconv.Itoa(pid), "status"))
+	if err != nil {
+		return ""
+	}
+
+	var uid string
+	for _, line := range strings.Split(string(data), "\n") {
+		// /proc/[pid]/status format: "Uid:	1000	1000	1000	1000" (real, effective, saved, filesystem)
+		if fields := strings.Fields(line); len(fields) >= 2 && fields[0] == "Uid:" {
+			uid = fields[1]
+			break
+		}
+	}
+
+	if r.users == nil {
+		r.users = readUsers()
+	}
+	if name, exists := r.users[uid]; exists {
+		return name
+	}
+	return uid
+}
+
+// commandLine returns the command line of a process
+// Kernel threads have no command line and are shown by name in brackets, like ps does.
+func (r *processResolver) commandLine(pid int, name string) string {
+	data, err := os.ReadFile(procPath(strconv.Itoa(pid), "cmdline"))
+	if err != nil || len(bytes.Trim(data, "\x00")) == 0 {
+		return "[" + name + "]"
+	}
+
+	// Arguments are separated by NUL bytes
+	if r.redact {
+		data, _, _ = bytes.Cut(data, []byte{0})
+	}
+	commandLine := strings.TrimSpace(string(bytes.ReplaceAll(data, []byte{0}, []byte{' '})))
+	if len(commandLine) > maxCommandLineLength {
+		commandLine = strings.ToValidUTF8(commandLine[:maxCommandLineLength], "") + "..."
+	}
+	return commandLine
+}
+
+// readUsers reads the user names keyed by user ID from the host's /etc/passwd
+func readUsers() map[string]string {
+	users := make(map[string]string)
+	data, err := os.ReadFile(etcPath("passwd"))
+	if err != nil {
+		return users // Owners are reported by user ID
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// /etc/passwd format: "name:password:uid:gid:gecos:home:shell"
+		fields := strings.Split(line, ":")
+		if len(fields) >= 3 && !strings.HasPrefix(line, "#") {
+			if _, exists := users[fields[2]]; !exists {
+				users[fields[2]] = fields[0]
+			}
+		}
+	}
+	return users
+}
@@ -0,0 +1,50 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "context"
+
+func init() {
+	RegisterCollector(sensorsCollector{})
+}
+
+// sensorsCollector gathers every thermal zone and hardware monitoring sensor
+type sensorsCollector struct{}
+
+// Name returns the section name
+func (sensorsCollector) Name() string {
+	return "sensors"
+}
+
+// Enabled reports whether sensor monitoring is enabled
+func (sensorsCollector) Enabled() bool {
+	return !features().DisableSensors
+}
+
+// Collect gathers thermal zones and hardware sensors into info.Sensors
+func (sensorsCollector) Collect(_ context.Context, info *SystemInfo) error {
+	sensors, err := getSensors()
+	if err != nil {
+		return err
+	}
+	info.Sensors = sensors
+	return nil
+}
+
+// CopySection copies the sensor readings from src into dst
+func (sensorsCollector) CopySection(dst, src *SystemInfo) {
+	dst.Sensors = src.Sensors
+}
@@ -0,0 +1,171 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hwmonInputPattern matches hwmon sensor input files, e.g. "temp1_input", "fan2_input" or "in0_input"
+var hwmonInputPattern = regexp.MustCompile(`^(temp|fan|in)(\d+)_input$`)
+
+// getSensors reads every thermal zone and hardware monitoring chip
+func getSensors() (SensorsInfo, error) {
+	sensors := SensorsInfo{
+		ThermalZones: []ThermalZone{},
+		Hwmon:        []HwmonChip{},
+	}
+
+	// Thermal zones are optional, e.g. they are usually missing in virtual machines
+	if zones, err := readThermalZones(false); err == nil {
+		sensors.ThermalZones = zones
+	}
+
+	chips, err := getHwmonChips()
+	if err != nil && !os.IsNotExist(err) {
+		return sensors, err
+	}
+	if chips != nil {
+		sensors.Hwmon = chips
+	}
+
+	sensors.SensorsIsAvailable = len(sensors.ThermalZones) > 0 || len(sensors.Hwmon) > 0
+	return sensors, nil
+}
+
+// getHwmonChips reads all hardware monitoring chips from /sys/class/hwmon
+func getHwmonChips() ([]HwmonChip, error) {
+	basePath := sysPath("class", "hwmon")
+
+	entries, err := os.ReadDir(basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	chips := []HwmonChip{}
+	for _, entry := range entries {
+		chipPath := filepath.Join(basePath, entry.Name())
+
+		// Older drivers expose their attributes in the device subdirectory instead
+		if _, err := os.Stat(filepath.Join(chipPath, "name")); err != nil {
+			chipPath = filepath.Join(chipPath, "device")
+		}
+
+		chip, err := readHwmonChip(chipPath)
+		if err != nil {
+			continue // Skip chips that cannot be read
+		}
+		chip.Device = entry.Name()
+		chips = append(chips, chip)
+	}
+
+	return chips, nil
+}
+
+// readHwmonChip reads the name and all sensors of a single hwmon chip
+func readHwmonChip(chipPath string) (HwmonChip, error) {
+	files, err := os.ReadDir(chipPath)
+	if err != nil {
+		return HwmonChip{}, err
+	}
+
+	chip := HwmonChip{
+		Name:         readSensorString(filepath.Join(chipPath, "name")),
+		Temperatures: []SensorReading{},
+		Fans:         []SensorReading{},
+		Voltages:     []SensorReading{},
+	}
+
+	// Collect sensor names in numeric order, e.g. temp1, temp2, ..., temp10
+	var names []string
+	for _, file := range files {
+		if hwmonInputPattern.MatchString(file.Name()) {
+			names = append(names, strings.TrimSuffix(file.Name(), "_input"))
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		mi := hwmonInputPattern.FindStringSubmatch(names[i] + "_input")
+		mj := hwmonInputPattern.FindStringSubmatch(names[j] + "_input")
+		if mi[1] != mj[1] {
+			return mi[1] < mj[1]
+		}
+		ni, _ := strconv.Atoi(mi[2])
+		nj, _ := strconv.Atoi(mj[2])
+		return ni < nj
+	})
+
+	for _, name := range names {
+		// Temperatures are in millidegrees Celsius, voltages in millivolts and fans in RPM
+		scale := 1000.0
+		if strings.HasPrefix(name, "fan") {
+			scale = 1
+		}
+
+		value, ok := readSensorValue(filepath.Join(chipPath, name+"_input"), scale)
+		if !ok {
+			continue // Skip sensors that cannot be read, e.g. disconnected fans
+		}
+
+		label := readSensorString(filepath.Join(chipPath, name+"_label"))
+		if label == "" {
+			label = name
+		}
+
+		reading := SensorReading{
+			Label: label,
+			Value: value,
+		}
+		reading.Min, _ = readSensorValue(filepath.Join(chipPath, name+"_min"), scale)
+		reading.Max, _ = readSensorValue(filepath.Join(chipPath, name+"_max"), scale)
+		reading.Crit, _ = readSensorValue(filepath.Join(chipPath, name+"_crit"), scale)
+
+		switch {
+		case strings.HasPrefix(name, "temp"):
+			chip.Temperatures = append(chip.Temperatures, reading)
+		case strings.HasPrefix(name, "fan"):
+			chip.Fans = append(chip.Fans, reading)
+		default:
+			chip.Voltages = append(chip.Voltages, reading)
+		}
+	}
+
+	return chip, nil
+}
+
+// readSensorString reads a sysfs attribute as a trimmed string, or "" if it does not exist
+func readSensorString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readSensorValue reads a numeric sysfs attribute and divides it by scale
+func readSensorValue(path string, scale float64) (float64, bool) {
+	value, err := strconv.ParseFloat(readSensorString(path), 64)
+	if err != nil {
+		return 0, false
+	}
+	return value / scale, true
+}
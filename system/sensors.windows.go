@@ -0,0 +1,28 @@
+//go:build windows
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+// getSensors is not implemented on Windows
+// Sensor readings are reported as unavailable
+func getSensors() (SensorsInfo, error) {
+	return SensorsInfo{
+		SensorsIsAvailable: false,
+		ThermalZones:       []ThermalZone{},
+		Hwmon:              []HwmonChip{},
+	}, nil
+}
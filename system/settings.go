@@ -0,0 +1,120 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Settings holds the collector configuration that can be changed while the agent is running
+type Settings struct {
+	Features                FeatureToggleStruct // Feature toggles
+	IgnoredMountpoints      []string            // Mountpoints ignored in addition to the defaults
+	IgnoredInterfaces       []string            // Interface patterns ignored in addition to the defaults
+	IncludedInterfaces      []string            // Interface patterns to report, all others are ignored when set
+	CPUThermalZone          int                 // Thermal zone for CPU temperature, -1 autodetects (Linux only)
+	ZFSRefreshInterval      time.Duration       // How long ZFS usage is cached before the zfs command is run again
+	HistoryMaxMemoryMB      int                 // Memory limit of the metrics history in megabytes, 0 disables it
+	HostPaths               HostPaths           // Where the host's filesystems are mounted (Linux only)
+	CgroupAware             bool                // Report memory and CPU against the limits of the agent's cgroup (Linux only)
+	TopProcesses            int                 // Number of processes listed by CPU and by memory
+	RedactCommandLines      bool                // Only report the executable of process command lines
+	ContainerSocket         string              // Unix socket of the Docker or Podman API
+	IncludedContainerLabels []string            // Only report containers with one of these labels, "key" or "key=value"
+	ExcludedContainerLabels []string            // Do not report containers with any of these labels, "key" or "key=value"
+}
+
+// HostPaths holds the locations of the host's filesystems
+// They differ from the defaults when the agent runs in a container with the host mounted read-only.
+type HostPaths struct {
+	Proc string // Host /proc
+	Sys  string // Host /sys
+	Etc  string // Host /etc
+	Root string // Host root filesystem, mountpoints are read below it
+}
+
+// defaultHostPaths are the locations of the filesystems when running directly on the host
+var defaultHostPaths = HostPaths{Proc: "/proc", Sys: "/sys", Etc: "/etc", Root: "/"}
+
+// currentSettings holds the settings in use, replaced as a whole so collectors never see a partial update
+var currentSettings atomic.Pointer[Settings]
+
+func init() {
+	currentSettings.Store(&Settings{CPUThermalZone: -1, ZFSRefreshInterval: time.Minute, HostPaths: defaultHostPaths, TopProcesses: 5, RedactCommandLines: true, ContainerSocket: defaultContainerSocket})
+}
+
+// ApplySettings replaces the collector configuration in a single step
+// Invalid values are replaced by the current ones.
+func ApplySettings(s Settings) {
+	var thermalZoneChanged bool
+	updateSettings(func(current *Settings) {
+		previous := *current
+		*current = s
+		current.IgnoredMountpoints = slices.Clone(s.IgnoredMountpoints)
+		current.IgnoredInterfaces = slices.Clone(s.IgnoredInterfaces)
+		current.IncludedInterfaces = slices.Clone(s.IncludedInterfaces)
+		current.IncludedContainerLabels = slices.Clone(s.IncludedContainerLabels)
+		current.ExcludedContainerLabels = slices.Clone(s.ExcludedContainerLabels)
+		if s.CPUThermalZone < -1 {
+			current.CPUThermalZone = previous.CPUThermalZone // -1 means autodetect
+		}
+		if s.ZFSRefreshInterval < 0 {
+			current.ZFSRefreshInterval = previous.ZFSRefreshInterval
+		}
+		if s.HistoryMaxMemoryMB < 0 {
+			current.HistoryMaxMemoryMB = previous.HistoryMaxMemoryMB
+		}
+		if s.ContainerSocket == "" {
+			current.ContainerSocket = defaultContainerSocket
+		}
+		if s.TopProcesses <= 0 {
+			current.TopProcesses = previous.TopProcesses
+		}
+		if s.HostPaths == (HostPaths{}) {
+			current.HostPaths = defaultHostPaths
+		}
+		thermalZoneChanged = current.CPUThermalZone != previous.CPUThermalZone || current.HostPaths.Sys != previous.HostPaths.Sys
+	})
+
+	// The autodetected zone is only valid for the configuration it was detected with
+	if thermalZoneChanged {
+		resetThermalZoneDetection()
+	}
+}
+
+// getSettings returns the settings in use
+func getSettings() *Settings {
+	return currentSettings.Load()
+}
+
+// features returns the feature toggles in use
+func features() FeatureToggleStruct {
+	return getSettings().Features
+}
+
+// updateSettings changes a copy of the settings in use and swaps it in
+func updateSettings(change func(*Settings)) {
+	for {
+		previous := currentSettings.Load()
+		next := *previous
+		change(&next)
+		if currentSettings.CompareAndSwap(previous, &next) {
+			return
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "testing"
+
+// setTestSettings changes the settings in use for the duration of a test
+func setTestSettings(t *testing.T, change func(*Settings)) {
+	t.Helper()
+	previous := *getSettings()
+	updateSettings(change)
+	t.Cleanup(func() {
+		updateSettings(func(s *Settings) { *s = previous })
+	})
+}
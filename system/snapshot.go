@@ -0,0 +1,194 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// snapshot holds the result of a single background collection
+type snapshot struct {
+	info        *SystemInfo
+	err         error
+	collectedAt time.Time // When the collection finished
+}
+
+// latestSnapshot stores the most recent background collection, nil until the collector starts
+var latestSnapshot atomic.Pointer[snapshot]
+
+// collectMutex serialises collections, so the counters used for utilisation and rates
+// are sampled by one collection at a time
+var collectMutex sync.Mutex
+
+// StartCollector refreshes the system information snapshot every interval in the background
+// The first snapshot is collected before returning. An interval of 0 disables the collector
+// and system information is collected on every request instead.
+func StartCollector(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		log.Println("Background collection disabled; collecting system information per request")
+		return
+	}
+
+	collect := func() {
+		collectMutex.Lock()
+		defer collectMutex.Unlock()
+
+		info, err := CollectSystemInfo(ctx)
+		if err != nil {
+			log.Printf("System info error: %v", err)
+		}
+		storeSnapshot(&snapshot{info: info, err: err, collectedAt: time.Now()})
+	}
+
+	collect()
+	log.Printf("Collecting system information every %s", interval)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				collect()
+			}
+		}
+	}()
+}
+
+// GetSnapshot returns the most recently collected system information
+// If the background collector is not running, the information is collected on demand,
+// one request at a time so the samplers are not advanced concurrently
+func GetSnapshot() (*SystemInfo, error) {
+	if s := latestSnapshot.Load(); s != nil {
+		return s.info, s.err
+	}
+
+	collectMutex.Lock()
+	defer collectMutex.Unlock()
+	s := collectOnDemand(context.Background())
+	return s.info, s.err
+}
+
+// GetFreshSnapshot returns the latest snapshot if it is younger than maxAge, otherwise it collects
+// a new one. The new snapshot replaces the background snapshot, so callers polling faster than the
+// background collector share a single collection instead of each running the collectors.
+// The collection advances the samplers every caller shares, so it always runs to completion;
+// a cancelled context only stops this caller from waiting for it.
+func GetFreshSnapshot(ctx context.Context, maxAge time.Duration) (*SystemInfo, error) {
+	if s := latestSnapshot.Load(); s != nil && time.Since(s.collectedAt) < maxAge {
+		return s.info, s.err
+	}
+
+	result := make(chan *snapshot, 1)
+	go func() {
+		result <- collectFreshSnapshot(maxAge)
+	}()
+
+	select {
+	case s := <-result:
+		return s.info, s.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// collectFreshSnapshot collects a new snapshot unless another caller did while this one was waiting
+func collectFreshSnapshot(maxAge time.Duration) *snapshot {
+	collectMutex.Lock()
+	defer collectMutex.Unlock()
+
+	s := latestSnapshot.Load()
+	if s == nil {
+		// The background collector is not running, so collect on demand as GetSnapshot does
+		return collectOnDemand(context.Background())
+	}
+	if time.Since(s.collectedAt) < maxAge {
+		return s // Another caller collected while this one was waiting
+	}
+
+	info, err := CollectSystemInfo(context.Background())
+	s = &snapshot{info: info, err: err, collectedAt: time.Now()}
+	storeSnapshot(s)
+	return s
+}
+
+// collectOnDemand collects a snapshot while the background collector is not running
+// The snapshot is added to the history, which then only has points for intervals in
+// which system information was requested. The collect mutex must be held.
+func collectOnDemand(ctx context.Context) *snapshot {
+	info, err := CollectSystemInfo(ctx)
+	s := &snapshot{info: info, err: err, collectedAt: time.Now()}
+	recordHistory(s.info, s.collectedAt)
+	return s
+}
+
+// storeSnapshot makes a collection the latest snapshot and adds it to the history
+func storeSnapshot(s *snapshot) {
+	latestSnapshot.Store(s)
+	recordHistory(s.info, s.collectedAt)
+}
+
+// GetSnapshotSections returns only the named sections of the latest snapshot
+// If the background collector is not running, only the named collectors are run on demand.
+// Sections whose collector does not implement SectionCopier are always collected on demand.
+func GetSnapshotSections(ctx context.Context, names []string) (*SystemInfo, error) {
+	s := latestSnapshot.Load()
+	if s == nil {
+		collectMutex.Lock()
+		defer collectMutex.Unlock()
+		return CollectSections(ctx, names)
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	info := newSystemInfo(s.info.CollectedAt)
+	for _, name := range names {
+		c, exists := GetCollector(name)
+		if !exists {
+			return nil, fmt.Errorf("unknown section %q", name)
+		}
+
+		copier, ok := c.(SectionCopier)
+		if !ok {
+			// Every collector of this package is a SectionCopier, so this only runs collectors
+			// registered elsewhere. They may keep samplers like the built-in ones, so they share
+			// the collect mutex and can delay a background collection by one run of the collector.
+			collectMutex.Lock()
+			err := runCollector(ctx, c, info)
+			collectMutex.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		copier.CopySection(info, s.info)
+		if message, failed := s.info.Errors[name]; failed {
+			info.setError(name, message)
+		}
+	}
+
+	return info, nil
+}
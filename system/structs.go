@@ -17,11 +17,25 @@ package system
 
 // CPUInfo contains CPU-related system metrics
 type CPUInfo struct {
-	LoadIsAvailable        bool `json:"load_is_available"`        // Whether load average data is available
-	Load1Percent           int  `json:"load1_percent"`            // 1-minute load average as percentage of CPU capacity
-	Load15Percent          int  `json:"load15_percent"`           // 15-minute load average as percentage of CPU capacity
-	TemperatureIsAvailable bool `json:"temperature_is_available"` // Whether CPU temperature data is available
-	TemperatureC           int  `json:"temperature_c"`            // CPU temperature in Celsius
+	LoadIsAvailable        bool       `json:"load_is_available"`        // Whether load average data is available
+	Load1Percent           int        `json:"load1_percent"`            // 1-minute load average as percentage of CPU capacity
+	Load15Percent          int        `json:"load15_percent"`           // 15-minute load average as percentage of CPU capacity
+	UsageIsAvailable       bool       `json:"usage_is_available"`       // Whether CPU utilisation data is available
+	Usage                  CPUUsage   `json:"usage"`                    // Aggregate CPU utilisation across all cores
+	Cores                  []CPUUsage `json:"cores"`                    // Per-core CPU utilisation
+	TemperatureIsAvailable bool       `json:"temperature_is_available"` // Whether CPU temperature data is available
+	TemperatureC           int        `json:"temperature_c"`            // CPU temperature in Celsius
+}
+
+// CPUUsage contains CPU utilisation percentages since the previous sample
+type CPUUsage struct {
+	Name          string  `json:"name"`           // "total" for the aggregate, otherwise the core name (e.g. "cpu0")
+	UsedPercent   float64 `json:"used_percent"`   // Time spent not idle or waiting on I/O as percentage
+	UserPercent   float64 `json:"user_percent"`   // Time spent in user mode (including nice) as percentage
+	SystemPercent float64 `json:"system_percent"` // Time spent in kernel mode (including irq and softirq) as percentage
+	IOWaitPercent float64 `json:"iowait_percent"` // Time spent waiting on I/O as percentage
+	StealPercent  float64 `json:"steal_percent"`  // Time stolen by the hypervisor as percentage
+	IdlePercent   float64 `json:"idle_percent"`   // Time spent idle as percentage
 }
 
 // MemoryInfo contains memory and swap usage metrics
@@ -38,22 +52,199 @@ type MemoryInfo struct {
 
 // MountPoint represents a filesystem mount point with usage statistics
 type MountPoint struct {
-	Path        string `json:"path"`         // Filesystem mount path
-	Name        string `json:"name"`         // Display name (same as path)
-	TotalMB     int    `json:"total_mb"`     // Total filesystem size in megabytes
-	UsedMB      int    `json:"used_mb"`      // Used space in megabytes
-	UsedPercent int    `json:"used_percent"` // Disk usage as percentage
+	Path              string `json:"path"`                // Filesystem mount path
+	Name              string `json:"name"`                // Display name (same as path)
+	TotalMB           int    `json:"total_mb"`            // Total filesystem size in megabytes
+	UsedMB            int    `json:"used_mb"`             // Used space in megabytes
+	UsedPercent       int    `json:"used_percent"`        // Disk usage as percentage
+	InodesIsAvailable bool   `json:"inodes_is_available"` // Whether inode usage is available for the filesystem
+	InodesTotal       uint64 `json:"inodes_total"`        // Total number of inodes
+	InodesUsed        uint64 `json:"inodes_used"`         // Number of inodes in use
+	InodesUsedPercent int    `json:"inodes_used_percent"` // Inode usage as percentage
+	IOIsAvailable     bool   `json:"io_is_available"`     // Whether I/O statistics are available for the backing device
+	IO                DiskIO `json:"io"`                  // I/O statistics of the backing block device
+}
+
+// DiskIO contains I/O statistics for a block device
+type DiskIO struct {
+	Device           string  `json:"device"`              // Block device name, e.g. "sda1"
+	ReadBytes        uint64  `json:"read_bytes"`          // Total bytes read
+	WriteBytes       uint64  `json:"write_bytes"`         // Total bytes written
+	ReadsCompleted   uint64  `json:"reads_completed"`     // Total read operations completed
+	WritesCompleted  uint64  `json:"writes_completed"`    // Total write operations completed
+	IOTimeMs         uint64  `json:"io_time_ms"`          // Total time the device was busy in milliseconds
+	ReadBytesPerSec  float64 `json:"read_bytes_per_sec"`  // Read throughput in bytes per second since the previous sample
+	WriteBytesPerSec float64 `json:"write_bytes_per_sec"` // Write throughput in bytes per second since the previous sample
+	ReadIOPS         float64 `json:"read_iops"`           // Read operations per second since the previous sample
+	WriteIOPS        float64 `json:"write_iops"`          // Write operations per second since the previous sample
+	UtilPercent      float64 `json:"util_percent"`        // Percentage of time the device was busy since the previous sample
+	deviceNumber     string  // Device major:minor number used to match mountpoints
+}
+
+// NetworkInfo contains traffic statistics for network interfaces
+type NetworkInfo struct {
+	NetworkIsAvailable bool               `json:"network_is_available"` // Whether network data is available
+	Interfaces         []NetworkInterface `json:"interfaces"`           // List of network interfaces that are not ignored
+}
+
+// NetworkInterface represents a network interface with its counters and rates
+type NetworkInterface struct {
+	Name            string  `json:"name"`               // Interface name, e.g. "eth0"
+	State           string  `json:"state"`              // Operational state, e.g. "up", "down" or "unknown"
+	SpeedMbps       int     `json:"speed_mbps"`         // Link speed in megabits per second, 0 if unknown
+	RxBytes         uint64  `json:"rx_bytes"`           // Total bytes received
+	TxBytes         uint64  `json:"tx_bytes"`           // Total bytes transmitted
+	RxPackets       uint64  `json:"rx_packets"`         // Total packets received
+	TxPackets       uint64  `json:"tx_packets"`         // Total packets transmitted
+	RxErrors        uint64  `json:"rx_errors"`          // Total receive errors
+	TxErrors        uint64  `json:"tx_errors"`          // Total transmit errors
+	RxDropped       uint64  `json:"rx_dropped"`         // Total received packets dropped
+	TxDropped       uint64  `json:"tx_dropped"`         // Total transmitted packets dropped
+	RxBytesPerSec   float64 `json:"rx_bytes_per_sec"`   // Receive rate in bytes per second since the previous sample
+	TxBytesPerSec   float64 `json:"tx_bytes_per_sec"`   // Transmit rate in bytes per second since the previous sample
+	RxPacketsPerSec float64 `json:"rx_packets_per_sec"` // Receive rate in packets per second since the previous sample
+	TxPacketsPerSec float64 `json:"tx_packets_per_sec"` // Transmit rate in packets per second since the previous sample
+}
+
+// SensorsInfo contains readings from every thermal zone and hardware monitoring sensor
+type SensorsInfo struct {
+	SensorsIsAvailable bool          `json:"sensors_is_available"` // Whether sensor data is available
+	ThermalZones       []ThermalZone `json:"thermal_zones"`        // All thermal zones from /sys/class/thermal
+	Hwmon              []HwmonChip   `json:"hwmon"`                // All hardware monitoring chips from /sys/class/hwmon
+}
+
+// ThermalZone represents a kernel thermal zone and its temperature
+type ThermalZone struct {
+	Name        string  `json:"name"`          // e.g. "thermal_zone0"
+	Type        string  `json:"type"`          // e.g. "x86_pkg_temp"
+	Temperature float64 `json:"temperature_c"` // in Celsius
+}
+
+// HwmonChip represents a hardware monitoring chip with its sensors
+type HwmonChip struct {
+	Device       string          `json:"device"`       // Device directory name, e.g. "hwmon0"
+	Name         string          `json:"name"`         // Chip driver name, e.g. "coretemp" or "nvme"
+	Temperatures []SensorReading `json:"temperatures"` // Temperature sensors in Celsius
+	Fans         []SensorReading `json:"fans"`         // Fan speed sensors in RPM
+	Voltages     []SensorReading `json:"voltages"`     // Voltage sensors in volts
+}
+
+// SensorReading represents a single sensor value and its thresholds
+// Thresholds that the chip does not report are 0
+type SensorReading struct {
+	Label string  `json:"label"` // Sensor label, e.g. "Package id 0", or the sensor name if unlabelled
+	Value float64 `json:"value"` // Current reading
+	Min   float64 `json:"min"`   // Minimum threshold
+	Max   float64 `json:"max"`   // Maximum threshold
+	Crit  float64 `json:"crit"`  // Critical threshold
+}
+
+// ProcessesInfo contains the processes using the most CPU and memory
+type ProcessesInfo struct {
+	ProcessesIsAvailable bool      `json:"processes_is_available"` // Whether process data is available
+	Total                int       `json:"total"`                  // Number of processes
+	TopCPU               []Process `json:"top_cpu"`                // Processes with the highest CPU usage, highest first
+	TopMemory            []Process `json:"top_memory"`             // Processes with the highest resident memory, highest first
+}
+
+// Process represents a single process and its resource usage
+type Process struct {
+	PID           int     `json:"pid"`            // Process ID
+	Name          string  `json:"name"`           // Process name, e.g. "postgres"
+	User          string  `json:"user"`           // Name of the user owning the process, or the user ID if it has no name
+	CommandLine   string  `json:"command_line"`   // Command line, only the executable when command lines are redacted
+	CPUPercent    float64 `json:"cpu_percent"`    // CPU usage as percentage of all cores since the previous sample
+	RSSMB         int     `json:"rss_mb"`         // Resident memory in megabytes
+	MemoryPercent float64 `json:"memory_percent"` // Resident memory as percentage of system memory
+}
+
+// ContainersInfo contains the containers of the Docker or Podman engine
+type ContainersInfo struct {
+	ContainersIsAvailable bool        `json:"containers_is_available"` // Whether the container engine could be reached
+	Containers            []Container `json:"containers"`              // Containers that are not filtered out, running or not
+}
+
+// Container represents a container with its state and resource usage
+type Container struct {
+	ID                   string  `json:"id"`                       // Short container ID
+	Name                 string  `json:"name"`                     // Container name
+	Image                string  `json:"image"`                    // Image the container was created from
+	State                string  `json:"state"`                    // e.g. "running", "exited" or "restarting"
+	Health               string  `json:"health"`                   // "healthy", "unhealthy" or "starting", empty without a health check
+	RestartCount         int     `json:"restart_count"`            // Number of times the engine restarted the container
+	StartedAt            int64   `json:"started_at"`               // Time the container was last started as Unix timestamp, 0 if never
+	UptimeSeconds        int64   `json:"uptime_seconds"`           // Time since the container was started, 0 when not running
+	StatsIsAvailable     bool    `json:"stats_is_available"`       // Whether resource usage is available, only for running containers
+	CPUPercent           float64 `json:"cpu_percent"`              // CPU usage as percentage of all cores since the previous sample
+	MemoryUsedMB         int     `json:"memory_used_mb"`           // Memory used in megabytes, excluding inactive page cache
+	MemoryLimitMB        int     `json:"memory_limit_mb"`          // Memory limit in megabytes, the system memory when unlimited
+	MemoryUsedPercent    int     `json:"memory_used_percent"`      // Memory usage as percentage of the limit
+	NetworkRxBytes       uint64  `json:"network_rx_bytes"`         // Total bytes received on all networks
+	NetworkTxBytes       uint64  `json:"network_tx_bytes"`         // Total bytes transmitted on all networks
+	NetworkRxBytesPerSec float64 `json:"network_rx_bytes_per_sec"` // Receive rate in bytes per second since the previous sample
+	NetworkTxBytesPerSec float64 `json:"network_tx_bytes_per_sec"` // Transmit rate in bytes per second since the previous sample
+}
+
+// PressureInfo contains the Pressure Stall Information of the system
+type PressureInfo struct {
+	PressureIsAvailable bool     `json:"pressure_is_available"` // Whether the kernel reports pressure stall information
+	CPU                 Pressure `json:"cpu"`                   // Time tasks were waiting for a CPU
+	Memory              Pressure `json:"memory"`                // Time tasks were waiting for memory to be reclaimed or swapped in
+	IO                  Pressure `json:"io"`                    // Time tasks were waiting for block I/O
+}
+
+// CgroupInfo contains the limits and usage of the cgroup the agent runs in
+type CgroupInfo struct {
+	CgroupIsAvailable         bool     `json:"cgroup_is_available"`          // Whether cgroup data is available
+	Version                   int      `json:"version"`                      // cgroup version, 1 or 2
+	Path                      string   `json:"path"`                         // cgroup path, e.g. "/system.slice/glance-agent.service"
+	MemoryLimitIsSet          bool     `json:"memory_limit_is_set"`          // Whether the cgroup has a memory limit
+	MemoryLimitMB             int      `json:"memory_limit_mb"`              // Memory limit in megabytes, 0 when unlimited
+	MemoryUsedMB              int      `json:"memory_used_mb"`               // Memory used by the cgroup in megabytes, excluding inactive page cache
+	MemoryUsedPercent         int      `json:"memory_used_percent"`          // Memory usage as percentage of the limit, or of system memory when unlimited
+	CPULimitIsSet             bool     `json:"cpu_limit_is_set"`             // Whether the cgroup has a CPU quota
+	CPULimitCores             float64  `json:"cpu_limit_cores"`              // CPU quota in cores, 0 when unlimited
+	CPUUsagePercent           float64  `json:"cpu_usage_percent"`            // CPU usage as percentage of the quota, or of all cores when unlimited, since the previous sample
+	CPUPeriods                uint64   `json:"cpu_periods"`                  // Total CPU quota enforcement periods
+	CPUThrottledPeriods       uint64   `json:"cpu_throttled_periods"`        // Total periods in which the cgroup was throttled
+	CPUThrottledMs            uint64   `json:"cpu_throttled_ms"`             // Total time the cgroup was throttled in milliseconds
+	MemoryPressureIsAvailable bool     `json:"memory_pressure_is_available"` // Whether memory pressure is available (cgroup v2 only)
+	MemoryPressure            Pressure `json:"memory_pressure"`              // Memory pressure stall information of the cgroup
+}
+
+// Pressure contains the Pressure Stall Information of a resource
+type Pressure struct {
+	Some PressureStats `json:"some"` // Time at least one task was stalled on the resource
+	Full PressureStats `json:"full"` // Time all non-idle tasks were stalled on the resource at once
+}
+
+// PressureStats contains the stall averages and total of one PSI line
+type PressureStats struct {
+	Avg10   float64 `json:"avg10"`    // Percentage of time stalled over the last 10 seconds
+	Avg60   float64 `json:"avg60"`    // Percentage of time stalled over the last 60 seconds
+	Avg300  float64 `json:"avg300"`   // Percentage of time stalled over the last 300 seconds
+	TotalUs uint64  `json:"total_us"` // Total stall time in microseconds
 }
 
 // SystemInfo is the main structure containing all system metrics
 //
 //nolint:revive // Keeping SystemInfo name for clarity in external packages
 type SystemInfo struct {
-	HostInfoIsAvailable bool         `json:"host_info_is_available"` // Whether host information is available
-	BootTime            int64        `json:"boot_time"`              // System boot time as Unix timestamp
-	Hostname            string       `json:"hostname"`               // System hostname
-	Platform            string       `json:"platform"`               // Operating system platform/distribution
-	CPU                 CPUInfo      `json:"cpu"`                    // CPU metrics and information
-	Memory              MemoryInfo   `json:"memory"`                 // Memory and swap usage information
-	MountPoints         []MountPoint `json:"mountpoints"`            // List of filesystem mount points with usage
+	CollectedAt         int64             `json:"collected_at"`           // Time the information was collected as Unix timestamp
+	Degraded            bool              `json:"degraded"`               // Whether any section failed to collect
+	Errors              map[string]string `json:"errors,omitempty"`       // Error message for each section that failed, keyed by section name
+	HostInfoIsAvailable bool              `json:"host_info_is_available"` // Whether host information is available
+	BootTime            int64             `json:"boot_time"`              // System boot time as Unix timestamp
+	Hostname            string            `json:"hostname"`               // System hostname
+	Platform            string            `json:"platform"`               // Operating system platform/distribution
+	CPU                 CPUInfo           `json:"cpu"`                    // CPU metrics and information
+	Memory              MemoryInfo        `json:"memory"`                 // Memory and swap usage information
+	MountPoints         []MountPoint      `json:"mountpoints"`            // List of filesystem mount points with usage
+	BlockDevices        []DiskIO          `json:"block_devices"`          // I/O statistics for each block device
+	Network             NetworkInfo       `json:"network"`                // Network interface statistics
+	Sensors             SensorsInfo       `json:"sensors"`                // All thermal zones and hardware monitoring sensors
+	Pressure            PressureInfo      `json:"pressure"`               // Pressure stall information for CPU, memory and I/O
+	Processes           ProcessesInfo     `json:"processes"`              // Processes using the most CPU and memory
+	Containers          ContainersInfo    `json:"containers"`             // Docker or Podman containers
+	Cgroup              CgroupInfo        `json:"cgroup"`                 // Limits and usage of the agent's cgroup
 }
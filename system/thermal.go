@@ -0,0 +1,51 @@
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "context"
+
+func init() {
+	RegisterCollector(thermalCollector{})
+}
+
+// thermalCollector gathers the CPU temperature
+type thermalCollector struct{}
+
+// Name returns the section name
+func (thermalCollector) Name() string {
+	return "thermal"
+}
+
+// Enabled reports whether temperature monitoring is enabled
+func (thermalCollector) Enabled() bool {
+	return !features().DisableTemperature
+}
+
+// Collect reads the CPU temperature into info.CPU
+func (thermalCollector) Collect(_ context.Context, info *SystemInfo) error {
+	temp := getCPUTemperature()
+	info.CPU.TemperatureIsAvailable = temp > 0 // If temperature is not positive, assume not available
+	if info.CPU.TemperatureIsAvailable {
+		info.CPU.TemperatureC = temp
+	}
+	return nil
+}
+
+// CopySection copies the CPU temperature from src into dst
+func (thermalCollector) CopySection(dst, src *SystemInfo) {
+	dst.CPU.TemperatureIsAvailable = src.CPU.TemperatureIsAvailable
+	dst.CPU.TemperatureC = src.CPU.TemperatureC
+}
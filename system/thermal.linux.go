@@ -24,9 +24,14 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 )
 
-var thermalZone = -1 // Default thermal zone for CPU temperature monitoring. autodetect.
+// detectedThermalZone caches the autodetected CPU thermal zone, -1 until detection has run
+var detectedThermalZone = struct {
+	sync.Mutex
+	zone int
+}{zone: -1}
 
 func SetCPUThermalZone(zone int) {
 	// Set the thermal zone for CPU temperature monitoring
@@ -35,18 +40,28 @@ func SetCPUThermalZone(zone int) {
 		return // Invalid zone, do nothing
 	}
 
-	thermalZone = zone
+	updateSettings(func(s *Settings) {
+		s.CPUThermalZone = zone
+	})
+	resetThermalZoneDetection()
 }
 
-type ThermalZone struct {
-	Name        string  // e.g. "thermal_zone0"
-	Type        string  // e.g. "x86_pkg_temp"
-	Temperature float64 // in Celsius
+// resetThermalZoneDetection forgets the autodetected CPU thermal zone, so it is detected again
+func resetThermalZoneDetection() {
+	detectedThermalZone.Lock()
+	defer detectedThermalZone.Unlock()
+	detectedThermalZone.zone = -1
 }
 
 // GetThermalZones returns all thermal zones and their temperature readings
+// Zones that cannot be read are logged and skipped
 func GetThermalZones() ([]ThermalZone, error) {
-	basePath := "/sys/class/thermal"
+	return readThermalZones(true)
+}
+
+// readThermalZones reads all thermal zones, optionally logging zones that are skipped
+func readThermalZones(logSkipped bool) ([]ThermalZone, error) {
+	basePath := sysPath("class", "thermal")
 	zones := []ThermalZone{}
 
 	entries, err := os.ReadDir(basePath)
@@ -67,7 +82,9 @@ func GetThermalZones() ([]ThermalZone, error) {
 		// Read type
 		typeData, err := os.ReadFile(typePath)
 		if err != nil {
-			log.Println("Skipping zone due to read error on type:", entry.Name(), err)
+			if logSkipped {
+				log.Println("Skipping zone due to read error on type:", entry.Name(), err)
+			}
 			continue // skip if we can't read type
 		}
 		zoneType := strings.TrimSpace(string(typeData))
@@ -75,12 +92,16 @@ func GetThermalZones() ([]ThermalZone, error) {
 		// Read temperature
 		tempData, err := os.ReadFile(tempPath)
 		if err != nil {
-			log.Println("Skipping zone due to read error on temp:", entry.Name(), err)
+			if logSkipped {
+				log.Println("Skipping zone due to read error on temp:", entry.Name(), err)
+			}
 			continue // skip if we can't read temp
 		}
 		tempMilli, err := strconv.Atoi(strings.TrimSpace(string(tempData)))
 		if err != nil {
-			log.Println("Skipping zone due to invalid temperature format:", entry.Name(), err)
+			if logSkipped {
+				log.Println("Skipping zone due to invalid temperature format:", entry.Name(), err)
+			}
 			continue // invalid number
 		}
 		tempC := float64(tempMilli) / 1000.0
@@ -93,8 +114,6 @@ func GetThermalZones() ([]ThermalZone, error) {
 		zones = append(zones, zone)
 	}
 
-	fmt.Println("Detected zones:", zones)
-
 	return zones, nil
 }
 
@@ -104,6 +123,13 @@ func SelectPrimaryCPUThermalZone() (ThermalZone, error) {
 		return ThermalZone{}, err
 	}
 
+	log.Println("Detected zones:", zones)
+
+	return selectPrimaryCPUThermalZone(zones)
+}
+
+// selectPrimaryCPUThermalZone returns the first zone with a preferred CPU sensor type
+func selectPrimaryCPUThermalZone(zones []ThermalZone) (ThermalZone, error) {
 	preferredTypes := []string{
 		"x86_pkg_temp",
 		"cpu_thermal",
@@ -131,25 +157,16 @@ func SelectPrimaryCPUThermalZone() (ThermalZone, error) {
 // getCPUTemperature reads CPU temperature from thermal zone
 // Returns temperature in Celsius, or 0 if unavailable
 func getCPUTemperature() int {
+	thermalZone := getSettings().CPUThermalZone
 	// Autodetect thermal zone if not set
 	if thermalZone < 0 {
-		zone, err := SelectPrimaryCPUThermalZone()
-		if err != nil {
-			thermalZone = 0 // Use the first detected zone
-		} else {
-			fmt.Println("Detected primary CPU thermal zone:", zone.Name)
-			strippedName := strings.TrimPrefix(zone.Name, "thermal_zone")
-			thermalZone, err = strconv.Atoi(strippedName)
-			if err != nil {
-				log.Println("ERROR: Invalid thermal zone name:", zone.Name)
-				return 0 // Invalid zone, return 0
-			}
+		var ok bool
+		if thermalZone, ok = autodetectCPUThermalZone(); !ok {
+			return 0 // Invalid zone, return 0
 		}
 	}
 
-	fmt.Println("Using thermal zone:", thermalZone)
-
-	data, err := os.ReadFile(fmt.Sprintf("/sys/class/thermal/thermal_zone%d/temp", thermalZone))
+	data, err := os.ReadFile(sysPath("class", "thermal", fmt.Sprintf("thermal_zone%d", thermalZone), "temp"))
 	if err != nil {
 		return 0 // Temperature not available
 	}
@@ -163,3 +180,29 @@ func getCPUTemperature() int {
 	// Convert from millidegrees to degrees Celsius
 	return temp / 1000
 }
+
+// autodetectCPUThermalZone returns the primary CPU thermal zone, detecting it on first use
+func autodetectCPUThermalZone() (int, bool) {
+	detectedThermalZone.Lock()
+	defer detectedThermalZone.Unlock()
+
+	if detectedThermalZone.zone >= 0 {
+		return detectedThermalZone.zone, true
+	}
+
+	thermalZone := 0 // Use the first detected zone
+	zone, err := SelectPrimaryCPUThermalZone()
+	if err == nil {
+		log.Println("Detected primary CPU thermal zone:", zone.Name)
+		strippedName := strings.TrimPrefix(zone.Name, "thermal_zone")
+		thermalZone, err = strconv.Atoi(strippedName)
+		if err != nil {
+			log.Println("ERROR: Invalid thermal zone name:", zone.Name)
+			return 0, false
+		}
+	}
+	log.Println("Using thermal zone:", thermalZone)
+
+	detectedThermalZone.zone = thermalZone
+	return thermalZone, true
+}
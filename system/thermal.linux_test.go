@@ -0,0 +1,54 @@
+//go:build linux
+
+package system
+
+// Copyright (C) Ava Glass <SuperNinja_4965>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program. If not, see <https://www.gnu.org/licenses/>.
+
+import "testing"
+
+func TestApplySettingsResetsThermalZoneDetection(t *testing.T) {
+	tests := []struct {
+		name      string
+		change    func(*Settings)
+		wantReset bool
+	}{
+		{name: "unchanged", change: func(*Settings) {}},
+		{name: "other setting changed", change: func(s *Settings) { s.TopProcesses++ }},
+		{name: "zone configured", change: func(s *Settings) { s.CPUThermalZone = 2 }, wantReset: true},
+		{name: "host /sys moved", change: func(s *Settings) { s.HostPaths.Sys = "/host/sys" }, wantReset: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestSettings(t, func(s *Settings) { s.CPUThermalZone = -1 })
+			t.Cleanup(resetThermalZoneDetection)
+			detectedThermalZone.Lock()
+			detectedThermalZone.zone = 5
+			detectedThermalZone.Unlock()
+
+			s := *getSettings()
+			tt.change(&s)
+			ApplySettings(s)
+
+			detectedThermalZone.Lock()
+			zone := detectedThermalZone.zone
+			detectedThermalZone.Unlock()
+			if reset := zone == -1; reset != tt.wantReset {
+				t.Errorf("detected zone = %d after the change, want reset %v", zone, tt.wantReset)
+			}
+		})
+	}
+}
@@ -28,6 +28,9 @@ func SetCPUThermalZone(_ int) {
 	log.Println("Thermal zone setting is not applicable on Windows. Ignoring value")
 }
 
+// resetThermalZoneDetection is a no-op on Windows, which has no thermal zones to detect
+func resetThermalZoneDetection() {}
+
 // getCPUTemperature attempts to get CPU temperature on Windows
 // Returns temperature in Celsius, or 0 if unavailable
 func getCPUTemperature() int {